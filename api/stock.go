@@ -1,39 +1,93 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/nagymarci/stock-screener/metrics"
 	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+//ErrUnknownSymbol is returned when the provider doesn't know the symbol
+var ErrUnknownSymbol = errors.New("unknown symbol")
+
 type StockScraper struct {
-	host string
+	mux    sync.RWMutex
+	host   string
+	client *http.Client
 }
 
 func New(h string) *StockScraper {
 	return &StockScraper{
 		host: h,
+		// the transport propagates the trace context to the provider
+		client: &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
 	}
 }
 
+//SetHost changes the provider url for the following requests
+func (ss *StockScraper) SetHost(h string) {
+	ss.mux.Lock()
+	defer ss.mux.Unlock()
+
+	ss.host = h
+}
+
+func (ss *StockScraper) getHost() string {
+	ss.mux.RLock()
+	defer ss.mux.RUnlock()
+
+	return ss.host
+}
+
 //Get returns the requested stock from the provider
-func (ss *StockScraper) Get(symbol string) (model.StockDataInfo, error) {
-	return ss.GetWithFields(symbol, []string{})
+func (ss *StockScraper) Get(ctx context.Context, symbol string) (model.StockDataInfo, error) {
+	return ss.GetWithFields(ctx, symbol, []string{})
 }
 
 //GetWithFields returns the stock from the provider with the requested fields filled
-func (ss *StockScraper) GetWithFields(symbol string, fields []string) (model.StockDataInfo, error) {
-	resp, err := http.Get(ss.host + symbol + "?fields=" + strings.Join(fields, ","))
+func (ss *StockScraper) GetWithFields(ctx context.Context, symbol string, fields []string) (model.StockDataInfo, error) {
+	ctx, span := tracing.Start(ctx, "StockScraper.GetWithFields", attribute.String("symbol", symbol), attribute.StringSlice("fields", fields))
+	defer span.End()
+
+	stockData, err := ss.getWithFields(ctx, symbol, fields)
+
+	return stockData, tracing.Error(span, err)
+}
+
+func (ss *StockScraper) getWithFields(ctx context.Context, symbol string, fields []string) (model.StockDataInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ss.getHost()+symbol+"?fields="+strings.Join(fields, ","), nil)
+
+	if err != nil {
+		return model.StockDataInfo{}, fmt.Errorf("Failed to create request for [%s] with error [%v]", symbol, err)
+	}
+
+	start := time.Now()
+	resp, err := ss.client.Do(req)
 
 	if err != nil {
+		metrics.ObserveProviderCall("error", time.Since(start), true)
 		return model.StockDataInfo{}, fmt.Errorf("Failed to get [%s] with error [%v]", symbol, err)
 	}
 
 	defer resp.Body.Close()
 
+	metrics.ObserveProviderCall(strconv.Itoa(resp.StatusCode), time.Since(start), resp.StatusCode >= 299)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return model.StockDataInfo{}, fmt.Errorf("%w: provider has no [%s]", ErrUnknownSymbol, symbol)
+	}
+
 	if resp.StatusCode >= 299 {
 		var response string
 		fmt.Fscan(resp.Body, &response)
@@ -50,3 +104,26 @@ func (ss *StockScraper) GetWithFields(symbol string, fields []string) (model.Sto
 
 	return stockData, nil
 }
+
+//Ping checks that the provider is reachable, any response below 500 counts as available
+func (ss *StockScraper) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ss.getHost(), nil)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create provider request with error [%v]", err)
+	}
+
+	resp, err := ss.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Failed to reach provider with error [%v]", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("Provider is unavailable, status code [%d]", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net/http"
+)
+
+//APIKeyHeader carries the api key of service callers
+const APIKeyHeader = "X-API-Key"
+
+//StaticKey is an api key given in the configuration
+type StaticKey struct {
+	Name string
+	Role Role
+	Key  string
+}
+
+type staticKeyAuthenticator struct {
+	keys map[[sha256.Size]byte]StaticKey
+}
+
+//NewStaticKeyAuthenticator accepts the configured api keys in the X-API-Key header
+func NewStaticKeyAuthenticator(keys []StaticKey) Authenticator {
+	// keys are looked up by their hash, so the comparison doesn't depend on how much of the key matches
+	byHash := make(map[[sha256.Size]byte]StaticKey, len(keys))
+	for _, key := range keys {
+		byHash[sha256.Sum256([]byte(key.Key))] = key
+	}
+
+	return &staticKeyAuthenticator{keys: byHash}
+}
+
+func (a *staticKeyAuthenticator) Authenticate(r *http.Request) (Principal, bool, error) {
+	presented := r.Header.Get(APIKeyHeader)
+	if presented == "" {
+		return Principal{}, false, nil
+	}
+
+	key, ok := a.keys[sha256.Sum256([]byte(presented))]
+	if !ok {
+		return Principal{}, false, errors.New("invalid api key")
+	}
+
+	return Principal{Subject: key.Name, Role: key.Role, Method: MethodAPIKey}, true, nil
+}
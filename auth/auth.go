@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+//Role grants access to the routes requiring the same or a lower role
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleViewer
+	RoleEditor
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleNone:   "none",
+	RoleViewer: "viewer",
+	RoleEditor: "editor",
+	RoleAdmin:  "admin",
+}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+//ParseRole returns the role of the name
+func ParseRole(name string) (Role, error) {
+	for role, roleName := range roleNames {
+		if role != RoleNone && strings.EqualFold(name, roleName) {
+			return role, nil
+		}
+	}
+
+	return RoleNone, fmt.Errorf("unknown role [%s], use viewer, editor or admin", name)
+}
+
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "apiKey"
+	MethodNone   = "none"
+)
+
+//Principal is the authenticated caller
+type Principal struct {
+	Subject string
+	Role    Role
+	Method  string
+}
+
+//Authenticator reads the caller from the request.
+//It returns false when the request doesn't carry its kind of credential, and an error when the credential is invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, bool, error)
+}
+
+type contextKey struct{}
+
+//FromContext returns the authenticated caller of the request
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
+
+//WithPrincipal returns a context carrying the caller
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+type chain []Authenticator
+
+//Chain tries the authenticators in order, the first one finding its credential decides
+func Chain(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+func (c chain) Authenticate(r *http.Request) (Principal, bool, error) {
+	for _, a := range c {
+		p, ok, err := a.Authenticate(r)
+		if ok || err != nil {
+			return p, ok, err
+		}
+	}
+
+	return Principal{}, false, nil
+}
+
+type anonymous struct{}
+
+//Anonymous grants admin access to every request, it is meant for local development only
+func Anonymous() Authenticator {
+	return anonymous{}
+}
+
+func (anonymous) Authenticate(r *http.Request) (Principal, bool, error) {
+	return Principal{Subject: "anonymous", Role: RoleAdmin, Method: MethodNone}, true, nil
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRequire(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Authenticate(NewStaticKeyAuthenticator([]StaticKey{
+		{Name: "dashboard", Role: RoleViewer, Key: "viewer-key"},
+		{Name: "ops", Role: RoleAdmin, Key: "admin-key"},
+	})))
+
+	stocks := router.PathPrefix("/stocks").Subrouter()
+	stocks.Use(Require(Rules{
+		Default: RoleViewer,
+		Routes:  map[string]Role{"DELETE /stocks/{symbol}": RoleAdmin},
+	}))
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	stocks.HandleFunc("/{symbol}", ok).Methods(http.MethodGet, http.MethodDelete, http.MethodOptions)
+
+	tests := []struct {
+		name   string
+		method string
+		key    string
+		status int
+	}{
+		{"anonymous read is unauthorized", http.MethodGet, "", http.StatusUnauthorized},
+		{"invalid key is unauthorized", http.MethodGet, "wrong", http.StatusUnauthorized},
+		{"viewer can read", http.MethodGet, "viewer-key", http.StatusOK},
+		{"viewer can't delete", http.MethodDelete, "viewer-key", http.StatusForbidden},
+		{"admin can delete", http.MethodDelete, "admin-key", http.StatusOK},
+		{"anonymous options is unauthorized", http.MethodOptions, "", http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, "/stocks/INTC", nil)
+			if test.key != "" {
+				req.Header.Set(APIKeyHeader, test.key)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != test.status {
+				t.Fatalf("expected [%d], got [%d] %s", test.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHighestRole(t *testing.T) {
+	t.Run("picks the highest known role from a list", func(t *testing.T) {
+		role := highestRole([]interface{}{"viewer", "owner", "editor"}, RoleViewer)
+
+		if role != RoleEditor {
+			t.Fatalf("expected editor, got [%s]", role)
+		}
+	})
+
+	t.Run("falls back to the default role", func(t *testing.T) {
+		role := highestRole(nil, RoleViewer)
+
+		if role != RoleViewer {
+			t.Fatalf("expected viewer, got [%s]", role)
+		}
+	})
+}
+
+// bearer accepts the token "viewer-token" of the Authorization header
+type bearer struct{}
+
+func (bearer) Authenticate(r *http.Request) (Principal, bool, error) {
+	switch r.Header.Get("Authorization") {
+	case "":
+		return Principal{}, false, nil
+	case "Bearer viewer-token":
+		return Principal{Subject: "browser", Role: RoleViewer, Method: MethodJWT}, true, nil
+	default:
+		return Principal{}, false, errors.New("invalid token")
+	}
+}
+
+func TestQueryToken(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(QueryToken("GET /stocks/stream", "GET /stocks/live"))
+	router.Use(Authenticate(bearer{}))
+	router.Use(Require(Rules{Default: RoleViewer}))
+
+	var query string
+	ok := func(w http.ResponseWriter, r *http.Request) { query = r.URL.RawQuery + r.RequestURI }
+	router.HandleFunc("/stocks/stream", ok).Methods(http.MethodGet)
+	router.HandleFunc("/stocks/live", ok).Methods(http.MethodGet)
+	router.HandleFunc("/stocks/{symbol}", ok).Methods(http.MethodGet)
+
+	tests := []struct {
+		name          string
+		target        string
+		authorization string
+		status        int
+	}{
+		{"stream accepts the query token", "/stocks/stream?symbols=INTC&access_token=viewer-token", "", http.StatusOK},
+		{"stream rejects an invalid query token", "/stocks/stream?access_token=wrong", "", http.StatusUnauthorized},
+		{"websocket handshake accepts the query token", "/stocks/live?access_token=viewer-token", "", http.StatusOK},
+		{"header wins over the query token", "/stocks/stream?access_token=wrong", "Bearer viewer-token", http.StatusOK},
+		{"other routes ignore the query token", "/stocks/INTC?access_token=viewer-token", "", http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query = ""
+			req := httptest.NewRequest(http.MethodGet, test.target, nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != test.status {
+				t.Fatalf("expected [%d], got [%d] %s", test.status, rec.Code, rec.Body.String())
+			}
+
+			if strings.Contains(query, AccessTokenParam) {
+				t.Fatalf("expected the token removed from the url, got [%s]", query)
+			}
+		})
+	}
+}
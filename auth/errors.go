@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/nagymarci/stock-screener/problem"
+)
+
+//NewUnauthorizedError is returned when the credentials are missing or invalid
+func NewUnauthorizedError(msg string) error {
+	return problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, msg)
+}
+
+//NewForbiddenError is returned when the caller doesn't have the required role
+func NewForbiddenError(msg string) error {
+	return problem.New(http.StatusForbidden, problem.CodeForbidden, msg)
+}
+
+// handleError writes the problem of the error, unauthorized responses tell the accepted schemes
+func handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if problem.From(err).Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer, ApiKey`)
+	}
+
+	problem.Write(w, r, err)
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/nagymarci/stock-commons/authorization"
+)
+
+type jwtAuthenticator struct {
+	check       func(w http.ResponseWriter, r *http.Request) error
+	rolesClaim  string
+	defaultRole Role
+}
+
+//NewJWTAuthenticator validates bearer tokens issued by the authorization server.
+//The role is the highest one listed in the roles claim, callers without one get the default role.
+func NewJWTAuthenticator(audience, authorizationServer, rolesClaim string, defaultRole Role) Authenticator {
+	middleware := authorization.CreateAuthorizationMiddleware(audience, authorizationServer)
+	// the errors are answered by the Authenticate middleware
+	middleware.Options.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err string) {}
+
+	return &jwtAuthenticator{
+		check:       middleware.CheckJWT,
+		rolesClaim:  rolesClaim,
+		defaultRole: defaultRole,
+	}
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (Principal, bool, error) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		return Principal{}, false, nil
+	}
+
+	// the token is put into the context of the request under "user"
+	if err := a.check(nil, r); err != nil {
+		return Principal{}, false, err
+	}
+
+	token, ok := r.Context().Value("user").(*jwt.Token)
+	if !ok {
+		return Principal{}, false, errors.New("token is missing from the request")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return Principal{}, false, errors.New("unexpected token claims")
+	}
+
+	subject, _ := claims["sub"].(string)
+
+	return Principal{
+		Subject: subject,
+		Role:    highestRole(claims[a.rolesClaim], a.defaultRole),
+		Method:  MethodJWT,
+	}, true, nil
+}
+
+// highestRole reads the roles from a space separated string or a list, unknown roles are ignored
+func highestRole(claim interface{}, defaultRole Role) Role {
+	names := []string{}
+
+	switch v := claim.(type) {
+	case string:
+		names = strings.Fields(v)
+	case []interface{}:
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	result := RoleNone
+	for _, name := range names {
+		if role, err := ParseRole(name); err == nil && role > result {
+			result = role
+		}
+	}
+
+	if result == RoleNone {
+		return defaultRole
+	}
+
+	return result
+}
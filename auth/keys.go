@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-screener/database"
+	"github.com/nagymarci/stock-screener/model"
+)
+
+// issued keys look like ss_<id>_<secret>, the id finds the stored hash
+const keyPrefix = "ss_"
+
+// lastUsed is written at most once in this period per key
+const touchResolution = time.Minute
+
+var scopeRoles = map[string]Role{
+	model.ScopeReadStocks:  RoleViewer,
+	model.ScopeWriteStocks: RoleEditor,
+	model.ScopeAdmin:       RoleAdmin,
+}
+
+//ErrInvalidKeyRequest is returned for key requests with unknown scopes or a missing name
+var ErrInvalidKeyRequest = errors.New("invalid api key request")
+
+//KeyRequest describes the key to issue, a zero TTL never expires
+type KeyRequest struct {
+	Name   string
+	Scopes []string
+	TTL    time.Duration
+}
+
+//IssuedKey is the stored key with the secret, the secret is only shown once
+type IssuedKey struct {
+	Key    model.APIKey `json:"key"`
+	Secret string       `json:"secret"`
+}
+
+//Keys issues the api keys stored in the database and authenticates their holders
+type Keys struct {
+	store *database.APIKeys
+}
+
+func NewKeys(store *database.APIKeys) *Keys {
+	return &Keys{store: store}
+}
+
+//Issue creates a new key
+func (k *Keys) Issue(ctx context.Context, request KeyRequest, createdBy string) (IssuedKey, error) {
+	return k.issue(ctx, request, createdBy, "")
+}
+
+func (k *Keys) issue(ctx context.Context, request KeyRequest, createdBy, rotatedFrom string) (IssuedKey, error) {
+	if err := validateKeyRequest(request); err != nil {
+		return IssuedKey{}, err
+	}
+
+	id, secret, err := generateKey()
+	if err != nil {
+		return IssuedKey{}, err
+	}
+
+	now := time.Now()
+	key := model.APIKey{
+		ID:          id,
+		Name:        request.Name,
+		Hash:        hashSecret(secret),
+		Scopes:      request.Scopes,
+		Created:     now,
+		CreatedBy:   createdBy,
+		RotatedFrom: rotatedFrom,
+	}
+	if request.TTL > 0 {
+		key.Expires = now.Add(request.TTL)
+	}
+
+	if err := k.store.Save(ctx, key); err != nil {
+		return IssuedKey{}, err
+	}
+
+	return IssuedKey{Key: key, Secret: keyPrefix + id + "_" + secret}, nil
+}
+
+//Rotate issues a replacement of the key with the same name, scopes and lifetime.
+//The old key keeps working for the grace period, so the callers can switch.
+func (k *Keys) Rotate(ctx context.Context, id string, gracePeriod time.Duration, rotatedBy string) (IssuedKey, error) {
+	old, err := k.store.Get(ctx, id)
+	if err != nil {
+		return IssuedKey{}, err
+	}
+
+	now := time.Now()
+	if !old.Active(now) {
+		return IssuedKey{}, fmt.Errorf("%w: key [%s] is expired or revoked", ErrInvalidKeyRequest, id)
+	}
+
+	ttl := time.Duration(0)
+	if !old.Expires.IsZero() {
+		ttl = old.Expires.Sub(old.Created)
+	}
+
+	issued, err := k.issue(ctx, KeyRequest{Name: old.Name, Scopes: old.Scopes, TTL: ttl}, rotatedBy, id)
+	if err != nil {
+		return IssuedKey{}, err
+	}
+
+	expires := now.Add(gracePeriod)
+	if !old.Expires.IsZero() && old.Expires.Before(expires) {
+		expires = old.Expires
+	}
+
+	if err := k.store.Expire(ctx, id, expires); err != nil {
+		return IssuedKey{}, err
+	}
+
+	return issued, nil
+}
+
+//Revoke disables the key immediately
+func (k *Keys) Revoke(ctx context.Context, id string) error {
+	return k.store.Revoke(ctx, id, time.Now())
+}
+
+//List returns every key without the secrets
+func (k *Keys) List(ctx context.Context) ([]model.APIKey, error) {
+	return k.store.GetAll(ctx)
+}
+
+//Authenticate accepts issued keys in the X-API-Key header, other keys are left to the next authenticator
+func (k *Keys) Authenticate(r *http.Request) (Principal, bool, error) {
+	presented := r.Header.Get(APIKeyHeader)
+	if !strings.HasPrefix(presented, keyPrefix) {
+		return Principal{}, false, nil
+	}
+
+	id, secret, ok := parseKey(presented)
+	if !ok {
+		return Principal{}, false, errors.New("invalid api key")
+	}
+
+	key, err := k.store.Get(r.Context(), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Principal{}, false, errors.New("invalid api key")
+	}
+	if err != nil {
+		return Principal{}, false, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashSecret(secret))) != 1 {
+		return Principal{}, false, errors.New("invalid api key")
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return Principal{}, false, fmt.Errorf("api key [%s] is expired or revoked", key.ID)
+	}
+
+	if err := k.store.Touch(r.Context(), key.ID, now, touchResolution); err != nil {
+		logrus.WithField("key", key.ID).Warnf("Failed to record key use: %v\n", err)
+	}
+
+	return Principal{Subject: key.Name, Role: scopesRole(key.Scopes), Method: MethodAPIKey}, true, nil
+}
+
+func validateKeyRequest(request KeyRequest) error {
+	if strings.TrimSpace(request.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidKeyRequest)
+	}
+
+	if len(request.Scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidKeyRequest)
+	}
+
+	for _, scope := range request.Scopes {
+		if _, ok := scopeRoles[scope]; !ok {
+			return fmt.Errorf("%w: unknown scope [%s], use %s, %s or %s", ErrInvalidKeyRequest, scope, model.ScopeReadStocks, model.ScopeWriteStocks, model.ScopeAdmin)
+		}
+	}
+
+	if request.TTL < 0 {
+		return fmt.Errorf("%w: ttl must not be negative", ErrInvalidKeyRequest)
+	}
+
+	return nil
+}
+
+// scopesRole returns the role granted by the broadest scope
+func scopesRole(scopes []string) Role {
+	result := RoleNone
+	for _, scope := range scopes {
+		if role := scopeRoles[scope]; role > result {
+			result = role
+		}
+	}
+
+	return result
+}
+
+func generateKey() (id string, secret string, err error) {
+	idBytes := make([]byte, 8)
+	secretBytes := make([]byte, 32)
+
+	if _, err = rand.Read(idBytes); err != nil {
+		return "", "", err
+	}
+	if _, err = rand.Read(secretBytes); err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(idBytes), base64.RawURLEncoding.EncodeToString(secretBytes), nil
+}
+
+// the secrets are random, so a plain hash is enough, there is nothing to brute force
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func parseKey(key string) (id string, secret string, ok bool) {
+	parts := strings.SplitN(strings.TrimPrefix(key, keyPrefix), "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nagymarci/stock-screener/model"
+)
+
+func TestKeys(t *testing.T) {
+	t.Run("generated key parses back to its id and secret", func(t *testing.T) {
+		id, secret, err := generateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsedID, parsedSecret, ok := parseKey(keyPrefix + id + "_" + secret)
+
+		if !ok || parsedID != id || parsedSecret != secret {
+			t.Fatalf("expected [%s] [%s], got [%s] [%s]", id, secret, parsedID, parsedSecret)
+		}
+	})
+
+	t.Run("broadest scope decides the role", func(t *testing.T) {
+		role := scopesRole([]string{model.ScopeReadStocks, model.ScopeWriteStocks})
+
+		if role != RoleEditor {
+			t.Fatalf("expected editor, got [%s]", role)
+		}
+	})
+
+	t.Run("rejects unknown scopes", func(t *testing.T) {
+		err := validateKeyRequest(KeyRequest{Name: "profile", Scopes: []string{"delete:everything"}})
+
+		if !errors.Is(err, ErrInvalidKeyRequest) {
+			t.Fatalf("expected invalid request, got [%v]", err)
+		}
+	})
+}
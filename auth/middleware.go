@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+//Authenticate puts the caller found by the authenticator into the request context.
+//Requests without credentials pass through, the routes decide with Require whether they need one.
+func Authenticate(authenticator Authenticator) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok, err := authenticator.Authenticate(r)
+
+			if err != nil {
+				logrus.WithField("path", r.URL.Path).Warnf("Authentication failed: %v\n", err)
+				handleError(w, r, NewUnauthorizedError(err.Error()))
+				return
+			}
+
+			if ok {
+				r = r.WithContext(WithPrincipal(r.Context(), p))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+//AccessTokenParam carries the bearer token of browser clients, EventSource and WebSocket can't set headers
+const AccessTokenParam = "access_token"
+
+//QueryToken moves the access_token query parameter of the listed "METHOD /path/template" routes into the
+//Authorization header, so the authenticators read it as a bearer token. The parameter is removed from the url,
+//the middleware should run before the tracing and logging ones.
+func QueryToken(routes ...string) mux.MiddlewareFunc {
+	allowed := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		allowed[route] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			token := query.Get(AccessTokenParam)
+
+			if token == "" || !allowed[r.Method+" "+routeTemplate(r)] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			query.Del(AccessTokenParam)
+
+			u := *r.URL
+			u.RawQuery = query.Encode()
+
+			r = r.Clone(r.Context())
+			r.URL = &u
+			r.RequestURI = u.RequestURI()
+
+			if r.Header.Get("Authorization") == "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// routeTemplate returns the path template of the matched route, it is empty for unmatched requests
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+
+	return ""
+}
+
+//Rules maps "METHOD /path/template" of the routes to the required role, other routes require the default
+type Rules struct {
+	Default Role
+	Routes  map[string]Role
+}
+
+func (rules Rules) required(r *http.Request) Role {
+	if role, ok := rules.Routes[r.Method+" "+routeTemplate(r)]; ok {
+		return role
+	}
+
+	return rules.Default
+}
+
+//Require answers 401 to anonymous callers and 403 to callers without the role required by the rules.
+//Preflight requests are answered by the CORS middleware before they reach the routes.
+func Require(rules Rules) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			required := rules.required(r)
+
+			if required == RoleNone {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			p, ok := FromContext(r.Context())
+
+			if !ok {
+				handleError(w, r, NewUnauthorizedError("authentication required"))
+				return
+			}
+
+			if p.Role < required {
+				logrus.WithField("subject", p.Subject).Warnf("Denied [%s %s] with role [%s]\n", r.Method, r.URL.Path, p.Role)
+				handleError(w, r, NewForbiddenError(fmt.Sprintf("role [%s] is required", required)))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
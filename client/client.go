@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	defaultBackoff = 200 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+	apiKeyHeader   = "X-API-Key"
+)
+
+//Options configures the client, Token and APIKey are alternatives, Token is sent as a bearer token
+type Options struct {
+	HTTPClient *http.Client
+	Token      string
+	APIKey     string
+	// failed requests are retried this many times on transport errors, 429 and 502-504
+	Retries int
+	// wait before the first retry, doubled for every following one, Retry-After takes precedence
+	Backoff time.Duration
+}
+
+//Client calls the v1 api of the screener
+type Client struct {
+	baseURL string
+	options Options
+}
+
+//Query selects a page of GetAllStocks, the zero value returns the first page with the server's defaults
+type Query struct {
+	// numeric field or ticker, prefixed with - for descending order
+	Sort string
+	// only these fields are filled in the returned stocks
+	Fields []string
+	Limit  int
+	Cursor string
+}
+
+//Page is a page of stocks, NextCursor is empty on the last page
+type Page struct {
+	Stocks     []model.StockDataInfo
+	NextCursor string
+}
+
+//New returns a client of the screener at baseURL, e.g. http://stock-screener:8080
+func New(baseURL string, options Options) *Client {
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: defaultTimeout}
+	}
+
+	if options.Backoff == 0 {
+		options.Backoff = defaultBackoff
+	}
+
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/v1",
+		options: options,
+	}
+}
+
+//RegisterStock adds the symbol to the watchlist, registering a registered symbol succeeds
+func (c *Client) RegisterStock(ctx context.Context, symbol string) error {
+	_, err := c.do(ctx, http.MethodPost, "/stocks/"+url.PathEscape(symbol), nil, nil)
+
+	return err
+}
+
+//GetStockInfo returns the stock of the symbol
+func (c *Client) GetStockInfo(ctx context.Context, symbol string) (model.StockDataInfo, error) {
+	var result model.StockDataInfo
+
+	_, err := c.do(ctx, http.MethodGet, "/stocks/"+url.PathEscape(symbol), nil, &result)
+
+	return result, err
+}
+
+//GetAllStocks returns the page of stocks selected by the query
+func (c *Client) GetAllStocks(ctx context.Context, query Query) (Page, error) {
+	values := url.Values{}
+
+	if query.Sort != "" {
+		values.Set("sort", query.Sort)
+	}
+	if len(query.Fields) > 0 {
+		values.Set("fields", strings.Join(query.Fields, ","))
+	}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+	if query.Cursor != "" {
+		values.Set("cursor", query.Cursor)
+	}
+
+	result := Page{Stocks: []model.StockDataInfo{}}
+
+	header, err := c.do(ctx, http.MethodGet, "/stocks", values, &result.Stocks)
+
+	if err != nil {
+		return result, err
+	}
+
+	result.NextCursor = nextCursor(header.Get("Link"))
+
+	return result, nil
+}
+
+//DeleteStock removes the stock of the symbol
+func (c *Client) DeleteStock(ctx context.Context, symbol string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/stocks/"+url.PathEscape(symbol), nil, nil)
+
+	return err
+}
+
+// do sends the request, retrying when it's worth it, and decodes the response into result
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, result interface{}) (http.Header, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		header, retryAfter, err := c.send(ctx, method, target, result)
+
+		if err == nil || attempt >= c.options.Retries || ctx.Err() != nil || !retryable(err) {
+			return header, err
+		}
+
+		wait := c.backoff(attempt)
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+
+		select {
+		case <-ctx.Done():
+			return header, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, target string, result interface{}) (http.Header, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req.Header.Set("Accept", "application/json, "+problem.ContentType)
+	if c.options.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.options.Token)
+	}
+	if c.options.APIKey != "" {
+		req.Header.Set(apiKeyHeader, c.options.APIKey)
+	}
+
+	resp, err := c.options.HTTPClient.Do(req)
+
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := decodeError(resp)
+		return resp.Header, apiErr.RetryAfter, apiErr
+	}
+
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return resp.Header, 0, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return resp.Header, 0, fmt.Errorf("stock-screener: decoding response of [%s %s]: %w", method, target, err)
+	}
+
+	return resp.Header, 0, nil
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.options.Backoff << attempt
+	if wait > maxBackoff || wait <= 0 {
+		wait = maxBackoff
+	}
+
+	// half of the wait is jittered, so clients failing together don't retry together
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+func decodeError(resp *http.Response) *Error {
+	result := &Error{Status: resp.StatusCode}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		result.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	var p problem.Problem
+	if mediaType == problem.ContentType && json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&p) == nil {
+		result.Code = p.Code
+		result.Detail = p.Detail
+	}
+
+	return result
+}
+
+func retryable(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		var urlErr *url.Error
+		return errors.As(err, &urlErr)
+	}
+
+	switch apiErr.Status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+var nextLink = regexp.MustCompile(`<([^>]*)>\s*;\s*rel="next"`)
+
+// nextCursor returns the cursor of the rel="next" link
+func nextCursor(link string) string {
+	match := nextLink.FindStringSubmatch(link)
+	if match == nil {
+		return ""
+	}
+
+	next, err := url.Parse(match[1])
+	if err != nil {
+		return ""
+	}
+
+	return next.Query().Get("cursor")
+}
@@ -0,0 +1,284 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nagymarci/stock-screener/api"
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/controllers"
+	"github.com/nagymarci/stock-screener/cors"
+	"github.com/nagymarci/stock-screener/database"
+	"github.com/nagymarci/stock-screener/health"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/openapi"
+	"github.com/nagymarci/stock-screener/problem"
+	"github.com/nagymarci/stock-screener/ratelimit"
+	"github.com/nagymarci/stock-screener/routes"
+	"github.com/nagymarci/stock-screener/settings"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var db *mongo.Database
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	req := testcontainers.ContainerRequest{
+		Image:        "mongo",
+		ExposedPorts: []string{"27017/tcp"},
+		WaitingFor:   wait.ForLog("Waiting for connections").WithStartupTimeout(time.Minute * 2),
+		Env: map[string]string{
+			"MONGO_INITDB_ROOT_USERNAME": "mongodb",
+			"MONGO_INITDB_ROOT_PASSWORD": "mongodb",
+			"MONGO_INITDB_DATABASE":      "stock-screener",
+		},
+	}
+
+	mongoC, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer mongoC.Terminate(ctx)
+	ip, err := mongoC.Host(ctx)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	port, err := mongoC.MappedPort(ctx, "27017")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	db = database.New(fmt.Sprintf("mongodb://%s:%s@%s:%d", "mongodb", "mongodb", ip, port.Int()))
+
+	code := m.Run()
+
+	os.Exit(code)
+}
+
+// newServer serves routes.Route with a fake provider knowing INTC and MSFT and failing on FAIL,
+// the responses are checked against the OpenAPI document
+func newServer(t *testing.T) *httptest.Server {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ticker := strings.TrimPrefix(r.URL.Path, "/")
+		if ticker == "FAIL" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if ticker != "INTC" && ticker != "MSFT" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode(model.StockDataInfo{Ticker: ticker, Price: 49.28, Eps: 5.43, Dividend: 0.33})
+	}))
+	t.Cleanup(provider.Close)
+
+	stockInfo := database.NewStockinfos(db)
+	controller := controllers.New(stockInfo, database.NewQuarantine(db), api.New(provider.URL+"/"), time.Hour, ratelimit.NewQuota(database.NewQuotas(db), "registration", 0), nil, nil, nil)
+
+	validator, err := openapi.NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator := auth.NewStaticKeyAuthenticator([]auth.StaticKey{
+		{Name: "viewer", Role: auth.RoleViewer, Key: "viewer-key"},
+		{Name: "admin", Role: auth.RoleAdmin, Key: "admin-key"},
+	})
+
+	router := routes.Route(controller, health.New(time.Second), settings.New(database.NewSettings(db), model.Settings{}), authenticator, auth.NewKeys(database.NewAPIKeys(db)), cors.Options{}, ratelimit.Budgets{}, validator, nil, nil)
+
+	server := httptest.NewServer(validator.Responses(router, func(r *http.Request, err error) {
+		t.Errorf("[%s %s] doesn't match the document: %v", r.Method, r.URL, err)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Cleanup(func() {
+		for _, ticker := range []string{"INTC", "MSFT"} {
+			stockInfo.Delete(context.Background(), model.Symbol{Ticker: ticker})
+		}
+	})
+
+	return server
+}
+
+func TestClient(t *testing.T) {
+	server := newServer(t)
+	ctx := context.Background()
+
+	admin := New(server.URL, Options{APIKey: "admin-key"})
+
+	t.Run("registers and returns stocks", func(t *testing.T) {
+		for _, symbol := range []string{"intc", "MSFT.US"} {
+			if err := admin.RegisterStock(ctx, symbol); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		stock, err := admin.GetStockInfo(ctx, "INTC")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stock.Ticker != "INTC" || stock.Price != 49.28 {
+			t.Fatalf("unexpected stock %+v", stock)
+		}
+
+		page, err := admin.GetAllStocks(ctx, Query{Sort: "ticker", Limit: 1})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(page.Stocks) != 1 || page.Stocks[0].Ticker != "INTC" || page.NextCursor == "" {
+			t.Fatalf("unexpected first page %+v", page)
+		}
+
+		page, err = admin.GetAllStocks(ctx, Query{Sort: "ticker", Fields: []string{"ticker", "price"}, Limit: 1, Cursor: page.NextCursor})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(page.Stocks) != 1 || page.Stocks[0].Ticker != "MSFT" || page.Stocks[0].Price != 49.28 {
+			t.Fatalf("unexpected second page %+v", page)
+		}
+	})
+	t.Run("pages over stocks missing the sort field", func(t *testing.T) {
+		stockInfo := database.NewStockinfos(db)
+		valued := model.StockDataInfo{Ticker: "AAPL", Price: 150}
+		valued.PeRatio5yr.Avg = 20
+		valued.PeRatio5yr.Min = 10
+
+		if err := stockInfo.Save(ctx, valued, model.Cause{Kind: model.CauseRegistration}); err != nil {
+			t.Fatal(err)
+		}
+		defer stockInfo.Delete(ctx, model.Symbol{Ticker: valued.Ticker})
+
+		// INTC and MSFT have no pe history, they come last in both directions
+		for sort, expected := range map[string]string{"peRatio5yr.avg": "AAPL,INTC,MSFT", "-peRatio5yr.avg": "AAPL,INTC,MSFT"} {
+			var tickers []string
+			query := Query{Sort: sort, Fields: []string{"ticker"}, Limit: 1}
+
+			for i := 0; i < 4; i++ {
+				page, err := admin.GetAllStocks(ctx, query)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, stock := range page.Stocks {
+					tickers = append(tickers, stock.Ticker)
+				}
+
+				if page.NextCursor == "" {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+
+			if strings.Join(tickers, ",") != expected {
+				t.Fatalf("[%s]: expected [%s], got %v", sort, expected, tickers)
+			}
+		}
+	})
+	t.Run("maps error responses", func(t *testing.T) {
+		_, err := admin.GetStockInfo(ctx, "AAPL")
+
+		var apiErr *Error
+		if !errors.Is(err, ErrNotFound) || !errors.As(err, &apiErr) || apiErr.Code != problem.CodeStockNotFound {
+			t.Fatalf("expected stock not found, got [%v]", err)
+		}
+
+		err = admin.RegisterStock(ctx, "AAPL")
+
+		if !errors.Is(err, ErrUnprocessable) || !errors.As(err, &apiErr) || apiErr.Code != problem.CodeUnknownSymbol {
+			t.Fatalf("expected unknown symbol, got [%v]", err)
+		}
+
+		err = admin.RegisterStock(ctx, "FAIL")
+
+		if !errors.Is(err, ErrProviderUnavailable) || !errors.As(err, &apiErr) || apiErr.Detail != "stock data provider is unavailable" {
+			t.Fatalf("expected provider unavailable without the provider url, got [%v]", err)
+		}
+
+		_, err = New(server.URL, Options{}).GetStockInfo(ctx, "INTC")
+
+		if !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("expected unauthorized, got [%v]", err)
+		}
+
+		err = New(server.URL, Options{APIKey: "viewer-key"}).DeleteStock(ctx, "INTC")
+
+		if !errors.Is(err, ErrForbidden) {
+			t.Fatalf("expected forbidden, got [%v]", err)
+		}
+	})
+	t.Run("deletes stocks", func(t *testing.T) {
+		if err := admin.DeleteStock(ctx, "MSFT"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := admin.GetStockInfo(ctx, "MSFT"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected not found after delete, got [%v]", err)
+		}
+
+		if err := admin.DeleteStock(ctx, "MSFT"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected not found deleting it again, got [%v]", err)
+		}
+	})
+}
+
+func TestRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("expected the bearer token, got [%s]", r.Header.Get("Authorization"))
+		}
+
+		if attempts < 3 {
+			problem.Write(w, r, problem.New(http.StatusServiceUnavailable, problem.CodeInternal, ""))
+			return
+		}
+
+		json.NewEncoder(w).Encode(model.StockDataInfo{Ticker: "INTC"})
+	}))
+	defer server.Close()
+
+	t.Run("retries unavailable responses", func(t *testing.T) {
+		stock, err := New(server.URL, Options{Token: "token", Retries: 2, Backoff: time.Millisecond}).GetStockInfo(context.Background(), "INTC")
+
+		if err != nil || stock.Ticker != "INTC" || attempts != 3 {
+			t.Fatalf("expected success on the third attempt, got [%v] after [%d]", err, attempts)
+		}
+	})
+	t.Run("gives up after the retries", func(t *testing.T) {
+		attempts = 0
+
+		_, err := New(server.URL, Options{Token: "token", Retries: 1, Backoff: time.Millisecond}).GetStockInfo(context.Background(), "INTC")
+
+		if !errors.Is(err, ErrServer) || attempts != 2 {
+			t.Fatalf("expected server error after two attempts, got [%v] after [%d]", err, attempts)
+		}
+	})
+}
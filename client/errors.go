@@ -0,0 +1,61 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// errors.Is matches the *Error responses with these by their status
+var (
+	ErrInvalidRequest      = errors.New("invalid request")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
+	ErrNotFound            = errors.New("not found")
+	ErrUnprocessable       = errors.New("unprocessable")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrProviderUnavailable = errors.New("provider unavailable")
+	ErrServer              = errors.New("server error")
+)
+
+var statusErrors = map[int]error{
+	http.StatusBadRequest:          ErrInvalidRequest,
+	http.StatusUnauthorized:        ErrUnauthorized,
+	http.StatusForbidden:           ErrForbidden,
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusUnprocessableEntity: ErrUnprocessable,
+	http.StatusTooManyRequests:     ErrRateLimited,
+	http.StatusBadGateway:          ErrProviderUnavailable,
+}
+
+//Error is an error response of the api, Code is one of the codes of the problem package, empty when the response had no problem body
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+	// set on 429, the api accepts the request again after this long
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	code := e.Code
+	if code == "" {
+		code = http.StatusText(e.Status)
+	}
+
+	if e.Detail == "" {
+		return fmt.Sprintf("stock-screener: %d %s", e.Status, code)
+	}
+
+	return fmt.Sprintf("stock-screener: %d %s: %s", e.Status, code, e.Detail)
+}
+
+//Is reports whether target is the class of the response status, e.g. ErrNotFound
+func (e *Error) Is(target error) bool {
+	if class, ok := statusErrors[e.Status]; ok {
+		return class == target
+	}
+
+	return target == ErrServer && e.Status >= http.StatusInternalServerError
+}
@@ -1,46 +1,262 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/nagymarci/stock-screener/api"
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/config"
 	"github.com/nagymarci/stock-screener/controllers"
 
 	"github.com/nagymarci/stock-screener/database"
+	"github.com/nagymarci/stock-screener/events"
+	"github.com/nagymarci/stock-screener/health"
+	"github.com/nagymarci/stock-screener/metrics"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/openapi"
+	"github.com/nagymarci/stock-screener/ratelimit"
 	"github.com/nagymarci/stock-screener/routes"
+	"github.com/nagymarci/stock-screener/rpc"
 	"github.com/nagymarci/stock-screener/service"
+	"github.com/nagymarci/stock-screener/settings"
+	"github.com/nagymarci/stock-screener/tracing"
+	"github.com/nagymarci/stock-screener/watch"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/robfig/cron/v3"
 
 	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const defaultReadinessTimeout = 2 * time.Second
+
 func main() {
 	log.SetFormatter(&log.JSONFormatter{})
 	rand.Seed(time.Now().UnixNano())
 
-	db := database.New(os.Getenv("DB_CONNECTION_URI"))
+	conf, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), conf.TracingExporter)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	db := database.New(conf.DBConnectionURI)
 	stockInfo := database.NewStockinfos(db)
 
-	stockscraper := api.New(os.Getenv("STOCKINFO_PROVIDER_URL"))
+	stockscraper := api.New(conf.ProviderURL)
 
-	controller := controllers.New(stockInfo, stockscraper)
+	quarantine := database.NewQuarantine(db)
 
-	router := routes.Route(controller)
+	migrateSymbols(stockInfo, quarantine)
 
-	updater := service.New(stockInfo, stockscraper, os.Getenv("STOCK_UPDATE_INTERVAL"), os.Getenv("PE_UPDATE_INTERVAL"), os.Getenv("DIV_UPDATE_INTERVAL"))
+	prometheus.MustRegister(metrics.NewFreshnessCollector(stockInfo))
 
-	c := cron.New()
-	_, err := c.AddFunc("CRON_TZ=America/New_York * 9-17 * * MON-FRI", updater.UpdateStocks)
+	registrations := ratelimit.NewQuota(database.NewQuotas(db), "registration", conf.RateLimit.DailyRegistrations)
+
+	// the changes written by the controller and the updater are streamed to the watchers
+	changes := watch.NewHub()
+
+	// the events are stored with the changes and delivered to the webhooks from the outbox
+	outbox := database.NewOutbox(db)
+	publisher := events.NewPublisher(outbox)
+	webhookStore := database.NewWebhooks(db)
+	deliveries := database.NewDeliveries(db)
+	webhooks := events.NewWebhooks(webhookStore, deliveries)
+
+	updater := service.New(stockInfo, quarantine, stockscraper, conf.Validation, conf.Intervals, changes, publisher)
+
+	controller := controllers.New(stockInfo, quarantine, stockscraper, conf.StaleThreshold, registrations, changes, publisher, updater)
+
+	checker := health.New(defaultReadinessTimeout)
+	checker.Add("mongo", func(ctx context.Context) error {
+		return database.Ping(ctx, db)
+	})
+	if conf.ReadinessCheckProvider {
+		checker.Add("provider", stockscraper.Ping)
+	}
+
+	// settings changed through the admin api are persisted and take precedence over the configuration
+	manager := settings.New(database.NewSettings(db), conf.Settings())
+	if err := manager.Load(context.Background()); err != nil {
+		log.Fatalln(err)
+	}
+
+	keys := auth.NewKeys(database.NewAPIKeys(db))
 
+	validator, err := openapi.NewValidator()
 	if err != nil {
-		log.Errorln(err)
+		log.Fatalln(err)
 	}
 
+	callers := authenticator(conf.Auth, keys)
+
+	// the REST api and the gRPC server share the budgets, so callers can't double them by switching
+	limits := budgets(conf.RateLimit)
+
+	router := routes.Route(controller, checker, manager, callers, keys, conf.CORS, limits, validator, changes, webhooks)
+
+	c := cron.New()
+	scheduler := service.NewScheduler(c, updater.UpdateStocks)
+
+	manager.Subscribe(func(s model.Settings) {
+		updater.Apply(s)
+		stockscraper.SetHost(s.ProviderURL)
+
+		if err := scheduler.Schedule(s.UpdateCron); err != nil {
+			log.Errorln(err)
+		}
+	})
+
 	c.Start()
 
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", os.Getenv("PORT")), router))
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	if conf.File != "" {
+		go settings.Watch(watchCtx, manager, conf.File, conf.WatchInterval, conf.Settings(), func() (model.Settings, error) {
+			reloaded, err := config.Load(os.Args[1:])
+			return reloaded.Settings(), err
+		})
+	}
+
+	dispatchCtx, stopDispatching := context.WithCancel(context.Background())
+	go events.NewDispatcher(outbox, webhookStore, deliveries, conf.Webhooks).Run(dispatchCtx)
+
+	server := &http.Server{Addr: conf.Addr(), Handler: router}
+	// the streams of the changes only end with the client otherwise, keeping the shutdown waiting
+	server.RegisterOnShutdown(changes.Close)
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalln(err)
+		}
+	}()
+
+	var grpcServer *rpc.Server
+	if addr := conf.GRPCAddr(); addr != "" {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		grpcServer = rpc.New(controller, changes, callers, limits)
+
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatalln(err)
+			}
+		}()
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-stop
+	stopWatching()
+	stopDispatching()
+
+	log.Infof("Received [%v], shutting down within [%v]\n", sig, conf.ShutdownTimeout)
+	shutdown(server, grpcServer, c, db, checker, shutdownTracing, conf.ShutdownTimeout, conf.ShutdownDrainDelay)
+}
+
+// budgets limits the routes calling the provider separately from the reads
+func budgets(conf config.RateLimit) ratelimit.Budgets {
+	provider := ratelimit.NewLimiter(conf.ProviderPerSecond, conf.ProviderBurst)
+
+	return ratelimit.Budgets{
+		Default: ratelimit.NewLimiter(conf.ReadPerSecond, conf.ReadBurst),
+		Routes: map[string]*ratelimit.Limiter{
+			"POST /v1/stocks/{symbol}": provider,
+			"POST /v1/stocks/import":   provider,
+		},
+		TrustForwarded: conf.TrustForwarded,
+	}
+}
+
+func authenticator(conf config.Auth, keys *auth.Keys) auth.Authenticator {
+	if conf.Disabled {
+		log.Warnln("Authentication is disabled, every caller is admin")
+		return auth.Anonymous()
+	}
+
+	// issued keys are recognized by their prefix, every other key is checked against the configured ones
+	authenticators := []auth.Authenticator{keys, auth.NewStaticKeyAuthenticator(conf.APIKeys)}
+	if conf.Server != "" {
+		authenticators = append(authenticators, auth.NewJWTAuthenticator(conf.Audience, conf.Server, conf.RolesClaim, conf.DefaultRole))
+	}
+
+	return auth.Chain(authenticators...)
+}
+
+//shutdown stops accepting requests, waits for the running update, then releases the connections.
+//The requests are still served for the drain delay, until the load balancers notice the service is not ready.
+func shutdown(server *http.Server, grpcServer *rpc.Server, c *cron.Cron, db *mongo.Database, checker *health.Checker, shutdownTracing func(context.Context) error, timeout, drainDelay time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	checker.Drain()
+
+	select {
+	case <-time.After(drainDelay):
+	case <-ctx.Done():
+	}
+
+	// no new updates are started from here, the returned context is done when the running one finishes
+	updaterDone := c.Stop()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Errorf("Failed to stop http server: %v\n", err)
+	}
+
+	if grpcServer != nil {
+		grpcServer.Shutdown(ctx)
+	}
+
+	select {
+	case <-updaterDone.Done():
+	case <-ctx.Done():
+		log.Errorln("Timed out waiting for the running update")
+	}
+
+	if err := database.Disconnect(ctx, db); err != nil {
+		log.Errorf("Failed to disconnect from database: %v\n", err)
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Errorf("Failed to flush traces: %v\n", err)
+	}
+
+	log.Infoln("Shutdown complete")
+}
+
+// migrateSymbols normalises the tickers stored before symbols were validated, it's a no-op once done
+func migrateSymbols(stockInfo *database.Stockinfos, quarantine *database.Quarantine) {
+	ctx := context.Background()
+
+	for name, migrate := range map[string]func(context.Context) (int, int, error){
+		"stockinfo":  stockInfo.MigrateSymbols,
+		"quarantine": quarantine.MigrateSymbols,
+	} {
+		migrated, duplicates, err := migrate(ctx)
+
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if migrated > 0 {
+			log.WithField("collection", name).Infof("Migrated [%d] tickers to the canonical form\n", migrated)
+		}
+
+		if duplicates > 0 {
+			log.WithField("collection", name).Warnf("Dropped [%d] duplicates of canonical tickers\n", duplicates)
+		}
+	}
 }
@@ -0,0 +1,613 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/tkanos/gonfig"
+
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/cors"
+	"github.com/nagymarci/stock-screener/events"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/service"
+	"github.com/nagymarci/stock-screener/validation"
+)
+
+//Config is the validated configuration of the server
+type Config struct {
+	DBConnectionURI        string
+	ProviderURL            string
+	Port                   int
+	GRPCPort               int
+	Intervals              service.Intervals
+	CronSpec               string
+	Concurrency            int
+	File                   string
+	WatchInterval          time.Duration
+	StaleThreshold         time.Duration
+	ShutdownTimeout        time.Duration
+	ShutdownDrainDelay     time.Duration
+	ReadinessCheckProvider bool
+	TracingExporter        string
+	Validation             validation.Rules
+	Auth                   Auth
+	CORS                   cors.Options
+	RateLimit              RateLimit
+	Webhooks               events.Options
+}
+
+//RateLimit configures the request budgets per client, a zero rate disables the budget
+type RateLimit struct {
+	ReadPerSecond      float64
+	ReadBurst          int
+	ProviderPerSecond  float64
+	ProviderBurst      int
+	TrustForwarded     bool
+	DailyRegistrations int
+}
+
+//Auth configures how the callers are authenticated
+type Auth struct {
+	Disabled    bool
+	Server      string
+	Audience    string
+	RolesClaim  string
+	DefaultRole auth.Role
+	APIKeys     []auth.StaticKey
+}
+
+//Settings returns the part of the configuration that can be changed at runtime
+func (c Config) Settings() model.Settings {
+	return model.Settings{
+		StockUpdateInterval: model.Duration(c.Intervals.Stock),
+		PeUpdateInterval:    model.Duration(c.Intervals.PeRatio),
+		DivUpdateInterval:   model.Duration(c.Intervals.DividendYield),
+		StockUpdateJitter:   model.Duration(c.Intervals.StockJitter),
+		HistoryUpdateJitter: model.Duration(c.Intervals.HistoryJitter),
+		UpdateCron:          c.CronSpec,
+		ProviderURL:         c.ProviderURL,
+		Concurrency:         c.Concurrency,
+	}
+}
+
+//Addr returns the address the server listens on
+func (c Config) Addr() string {
+	return fmt.Sprintf(":%d", c.Port)
+}
+
+//GRPCAddr returns the address the gRPC server listens on, empty when it's disabled
+func (c Config) GRPCAddr() string {
+	if c.GRPCPort == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(":%d", c.GRPCPort)
+}
+
+// every value is read as a string, so invalid values are reported instead of being silently ignored
+type source struct {
+	DBConnectionURI        setting `json:"dbConnectionUri" env:"DB_CONNECTION_URI"`
+	ProviderURL            setting `json:"stockinfoProviderUrl" env:"STOCKINFO_PROVIDER_URL"`
+	Port                   setting `json:"port" env:"PORT"`
+	GRPCPort               setting `json:"grpcPort" env:"GRPC_PORT"`
+	StockUpdateInterval    setting `json:"stockUpdateInterval" env:"STOCK_UPDATE_INTERVAL"`
+	PeUpdateInterval       setting `json:"peUpdateInterval" env:"PE_UPDATE_INTERVAL"`
+	DivUpdateInterval      setting `json:"divUpdateInterval" env:"DIV_UPDATE_INTERVAL"`
+	StockUpdateJitter      setting `json:"stockUpdateJitter" env:"STOCK_UPDATE_JITTER"`
+	HistoryUpdateJitter    setting `json:"historyUpdateJitter" env:"HISTORY_UPDATE_JITTER"`
+	UpdateCron             setting `json:"updateCron" env:"UPDATE_CRON"`
+	UpdateConcurrency      setting `json:"updateConcurrency" env:"UPDATE_CONCURRENCY"`
+	ConfigWatchInterval    setting `json:"configWatchInterval" env:"CONFIG_WATCH_INTERVAL"`
+	StaleThreshold         setting `json:"staleThreshold" env:"STALE_THRESHOLD"`
+	ShutdownTimeout        setting `json:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT"`
+	ShutdownDrainDelay     setting `json:"shutdownDrainDelay" env:"SHUTDOWN_DRAIN_DELAY"`
+	ReadinessCheckProvider setting `json:"readinessCheckProvider" env:"READINESS_CHECK_PROVIDER"`
+	TracingExporter        setting `json:"tracingExporter" env:"TRACING_EXPORTER"`
+	MaxPrice               setting `json:"validationMaxPrice" env:"VALIDATION_MAX_PRICE"`
+	MaxPeRatio             setting `json:"validationMaxPeRatio" env:"VALIDATION_MAX_PE_RATIO"`
+	MaxDividendYield       setting `json:"validationMaxDividendYield" env:"VALIDATION_MAX_DIVIDEND_YIELD"`
+	MaxPriceChange         setting `json:"validationMaxPriceChange" env:"VALIDATION_MAX_PRICE_CHANGE"`
+	MaxDividendChange      setting `json:"validationMaxDividendChange" env:"VALIDATION_MAX_DIVIDEND_CHANGE"`
+	AuthDisabled           setting `json:"authDisabled" env:"AUTH_DISABLED"`
+	AuthServer             setting `json:"authServer" env:"AUTH_SERVER"`
+	AuthAudience           setting `json:"authAudience" env:"AUTH_AUDIENCE"`
+	AuthRolesClaim         setting `json:"authRolesClaim" env:"AUTH_ROLES_CLAIM"`
+	AuthDefaultRole        setting `json:"authDefaultRole" env:"AUTH_DEFAULT_ROLE"`
+	APIKeys                setting `json:"apiKeys" env:"API_KEYS"`
+	CORSAllowedOrigins     setting `json:"corsAllowedOrigins" env:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedMethods     setting `json:"corsAllowedMethods" env:"CORS_ALLOWED_METHODS"`
+	CORSAllowedHeaders     setting `json:"corsAllowedHeaders" env:"CORS_ALLOWED_HEADERS"`
+	CORSExposedHeaders     setting `json:"corsExposedHeaders" env:"CORS_EXPOSED_HEADERS"`
+	CORSAllowCredentials   setting `json:"corsAllowCredentials" env:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge             setting `json:"corsMaxAge" env:"CORS_MAX_AGE"`
+	RateLimitRead          setting `json:"rateLimitRead" env:"RATE_LIMIT_READ"`
+	RateLimitReadBurst     setting `json:"rateLimitReadBurst" env:"RATE_LIMIT_READ_BURST"`
+	RateLimitProvider      setting `json:"rateLimitProvider" env:"RATE_LIMIT_PROVIDER"`
+	RateLimitProviderBurst setting `json:"rateLimitProviderBurst" env:"RATE_LIMIT_PROVIDER_BURST"`
+	RateLimitTrustProxy    setting `json:"rateLimitTrustProxy" env:"RATE_LIMIT_TRUST_PROXY"`
+	DailyRegistrationQuota setting `json:"dailyRegistrationQuota" env:"DAILY_REGISTRATION_QUOTA"`
+	WebhookPollInterval    setting `json:"webhookPollInterval" env:"WEBHOOK_POLL_INTERVAL"`
+	WebhookTimeout         setting `json:"webhookTimeout" env:"WEBHOOK_TIMEOUT"`
+	WebhookMaxAttempts     setting `json:"webhookMaxAttempts" env:"WEBHOOK_MAX_ATTEMPTS"`
+}
+
+// setting accepts unquoted numbers and booleans from the file as well
+type setting string
+
+func (s *setting) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case string:
+		*s = setting(v)
+	case nil:
+		*s = ""
+	default:
+		*s = setting(fmt.Sprint(v))
+	}
+
+	return nil
+}
+
+func defaults() source {
+	rules := validation.DefaultRules()
+
+	return source{
+		StockUpdateJitter:   "30m",
+		HistoryUpdateJitter: "24h",
+		UpdateCron:          "CRON_TZ=America/New_York * 9-17 * * MON-FRI",
+		UpdateConcurrency:   "1",
+		ConfigWatchInterval: "30s",
+		// the updater only runs on weekdays, so the data must survive a weekend before it's reported stale
+		StaleThreshold:         "72h",
+		ShutdownTimeout:        "30s",
+		// the load balancers need a few readiness probes to notice the draining
+		ShutdownDrainDelay:     "5s",
+		ReadinessCheckProvider: "false",
+		TracingExporter:        "none",
+		MaxPrice:               formatLimit(rules.MaxPrice),
+		MaxPeRatio:             formatLimit(rules.MaxPeRatio),
+		MaxDividendYield:       formatLimit(rules.MaxDividendYield),
+		MaxPriceChange:         formatLimit(rules.MaxPriceChange),
+		MaxDividendChange:      formatLimit(rules.MaxDividendChange),
+		AuthDisabled:           "false",
+		AuthRolesClaim:         "roles",
+		AuthDefaultRole:        "viewer",
+		CORSAllowedMethods:     "GET,POST,PUT,PATCH,DELETE",
+		CORSAllowedHeaders:     "Authorization,Content-Type,X-API-Key",
+		CORSExposedHeaders:     "Link,Content-Disposition",
+		CORSAllowCredentials:   "false",
+		CORSMaxAge:             "10m",
+		RateLimitRead:          "20",
+		RateLimitReadBurst:     "40",
+		RateLimitProvider:      "0.2",
+		RateLimitProviderBurst: "5",
+		RateLimitTrustProxy:    "false",
+		DailyRegistrationQuota: "100",
+		WebhookPollInterval:    "5s",
+		WebhookTimeout:         "10s",
+		// with the doubling backoff the last attempt is about three and a half hours after the event
+		WebhookMaxAttempts: "12",
+	}
+}
+
+//Load reads the configuration from the file, the environment and the command line flags, in increasing precedence.
+//The file is given by the -config flag or the CONFIG_FILE environment variable, it can be YAML or JSON.
+func Load(args []string) (Config, error) {
+	src := defaults()
+
+	fs := flag.NewFlagSet("stock-screener", flag.ContinueOnError)
+	file := fs.String("config", os.Getenv("CONFIG_FILE"), "path of the YAML or JSON configuration file")
+	flags := registerFlags(fs, &src)
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if err := gonfig.GetConf(*file, &src); err != nil {
+		return Config{}, fmt.Errorf("failed to read configuration file [%s]: %v", *file, err)
+	}
+
+	// flags are applied last, gonfig only knows the file and the environment
+	fs.Visit(func(f *flag.Flag) {
+		if apply, ok := flags[f.Name]; ok {
+			apply()
+		}
+	})
+
+	c, err := src.parse()
+	c.File = *file
+
+	return c, err
+}
+
+// registerFlags adds a string flag named after the file key of every field
+func registerFlags(fs *flag.FlagSet, src *source) map[string]func() {
+	flags := make(map[string]func())
+	value := reflect.ValueOf(src).Elem()
+	typ := value.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := field.Tag.Get("json")
+		target := value.Field(i)
+		flagValue := fs.String(name, "", fmt.Sprintf("overrides %s", field.Tag.Get("env")))
+
+		flags[name] = func() {
+			target.SetString(*flagValue)
+		}
+	}
+
+	return flags
+}
+
+func (s source) parse() (Config, error) {
+	p := parser{}
+
+	c := Config{
+		DBConnectionURI: p.mongoURI("DB_CONNECTION_URI", s.DBConnectionURI),
+		ProviderURL:     p.url("STOCKINFO_PROVIDER_URL", s.ProviderURL),
+		Port:            p.port("PORT", s.Port),
+		GRPCPort:        p.optionalPort("GRPC_PORT", s.GRPCPort),
+		Intervals: service.Intervals{
+			Stock:         p.interval("STOCK_UPDATE_INTERVAL", s.StockUpdateInterval),
+			PeRatio:       p.interval("PE_UPDATE_INTERVAL", s.PeUpdateInterval),
+			DividendYield: p.interval("DIV_UPDATE_INTERVAL", s.DivUpdateInterval),
+			StockJitter:   p.duration("STOCK_UPDATE_JITTER", s.StockUpdateJitter),
+			HistoryJitter: p.duration("HISTORY_UPDATE_JITTER", s.HistoryUpdateJitter),
+		},
+		CronSpec:               p.cron("UPDATE_CRON", s.UpdateCron),
+		Concurrency:            p.concurrency("UPDATE_CONCURRENCY", s.UpdateConcurrency),
+		WatchInterval:          p.interval("CONFIG_WATCH_INTERVAL", s.ConfigWatchInterval),
+		StaleThreshold:         p.interval("STALE_THRESHOLD", s.StaleThreshold),
+		ShutdownTimeout:        p.interval("SHUTDOWN_TIMEOUT", s.ShutdownTimeout),
+		ShutdownDrainDelay:     p.duration("SHUTDOWN_DRAIN_DELAY", s.ShutdownDrainDelay),
+		ReadinessCheckProvider: p.bool("READINESS_CHECK_PROVIDER", s.ReadinessCheckProvider),
+		TracingExporter:        string(s.TracingExporter),
+		Validation: validation.Rules{
+			MaxPrice:          p.limit("VALIDATION_MAX_PRICE", s.MaxPrice),
+			MaxPeRatio:        p.limit("VALIDATION_MAX_PE_RATIO", s.MaxPeRatio),
+			MaxDividendYield:  p.limit("VALIDATION_MAX_DIVIDEND_YIELD", s.MaxDividendYield),
+			MaxPriceChange:    p.limit("VALIDATION_MAX_PRICE_CHANGE", s.MaxPriceChange),
+			MaxDividendChange: p.limit("VALIDATION_MAX_DIVIDEND_CHANGE", s.MaxDividendChange),
+		},
+		Auth:      p.auth(s),
+		CORS:      p.cors(s),
+		RateLimit: p.rateLimit(s),
+		Webhooks: events.Options{
+			PollInterval: p.interval("WEBHOOK_POLL_INTERVAL", s.WebhookPollInterval),
+			Timeout:      p.interval("WEBHOOK_TIMEOUT", s.WebhookTimeout),
+			MaxAttempts:  p.positive("WEBHOOK_MAX_ATTEMPTS", s.WebhookMaxAttempts),
+		},
+	}
+
+	// the delay is part of the shutdown, the requests would have no time to finish otherwise
+	if c.ShutdownTimeout > 0 && c.ShutdownDrainDelay >= c.ShutdownTimeout {
+		p.fail("SHUTDOWN_DRAIN_DELAY", "must be shorter than SHUTDOWN_TIMEOUT [%v], got [%v]", c.ShutdownTimeout, c.ShutdownDrainDelay)
+	}
+
+	return c, p.err()
+}
+
+//Error lists every invalid setting, so they can be fixed at once
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// parser collects the problems instead of stopping at the first one
+type parser struct {
+	problems []string
+}
+
+func (p *parser) fail(name, format string, args ...interface{}) {
+	p.problems = append(p.problems, name+": "+fmt.Sprintf(format, args...))
+}
+
+func (p *parser) err() error {
+	if len(p.problems) == 0 {
+		return nil
+	}
+
+	return &Error{Problems: p.problems}
+}
+
+func (p *parser) required(name string, value setting) bool {
+	if strings.TrimSpace(string(value)) == "" {
+		p.fail(name, "is required")
+		return false
+	}
+
+	return true
+}
+
+func (p *parser) parseDuration(name string, value setting) (time.Duration, bool) {
+	if !p.required(name, value) {
+		return 0, false
+	}
+
+	d, err := time.ParseDuration(string(value))
+	if err != nil {
+		p.fail(name, "invalid duration [%s], use e.g. 90m or 24h", value)
+		return 0, false
+	}
+
+	return d, true
+}
+
+func (p *parser) duration(name string, value setting) time.Duration {
+	d, ok := p.parseDuration(name, value)
+
+	if ok && d < 0 {
+		p.fail(name, "must not be negative, got [%s]", value)
+	}
+
+	return d
+}
+
+func (p *parser) interval(name string, value setting) time.Duration {
+	d, ok := p.parseDuration(name, value)
+
+	if ok && d <= 0 {
+		p.fail(name, "must be positive, got [%s]", value)
+	}
+
+	return d
+}
+
+func (p *parser) url(name string, value setting) string {
+	if !p.required(name, value) {
+		return ""
+	}
+
+	u, err := url.Parse(string(value))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		p.fail(name, "invalid http(s) url [%s]", value)
+	}
+
+	return string(value)
+}
+
+func (p *parser) mongoURI(name string, value setting) string {
+	if !p.required(name, value) {
+		return ""
+	}
+
+	if !strings.HasPrefix(string(value), "mongodb://") && !strings.HasPrefix(string(value), "mongodb+srv://") {
+		p.fail(name, "must start with mongodb:// or mongodb+srv://")
+	}
+
+	return string(value)
+}
+
+func (p *parser) port(name string, value setting) int {
+	if !p.required(name, value) {
+		return 0
+	}
+
+	port, err := strconv.Atoi(string(value))
+	if err != nil || port < 1 || port > 65535 {
+		p.fail(name, "invalid port [%s], must be between 1 and 65535", value)
+	}
+
+	return port
+}
+
+// optionalPort returns 0 when the value is empty
+func (p *parser) optionalPort(name string, value setting) int {
+	if strings.TrimSpace(string(value)) == "" {
+		return 0
+	}
+
+	return p.port(name, value)
+}
+
+func (p *parser) cron(name string, value setting) string {
+	if !p.required(name, value) {
+		return ""
+	}
+
+	if _, err := cron.ParseStandard(string(value)); err != nil {
+		p.fail(name, "invalid cron spec [%s]: %v", value, err)
+	}
+
+	return string(value)
+}
+
+func (p *parser) concurrency(name string, value setting) int {
+	n, err := strconv.Atoi(string(value))
+	if err != nil || n < 1 {
+		p.fail(name, "invalid concurrency [%s], must be a positive number", value)
+	}
+
+	return n
+}
+
+func (p *parser) positive(name string, value setting) int {
+	n, err := strconv.Atoi(string(value))
+	if err != nil || n < 1 {
+		p.fail(name, "invalid number [%s], must be positive", value)
+	}
+
+	return n
+}
+
+func (p *parser) auth(s source) Auth {
+	a := Auth{
+		Disabled:    p.bool("AUTH_DISABLED", s.AuthDisabled),
+		Server:      string(s.AuthServer),
+		Audience:    string(s.AuthAudience),
+		RolesClaim:  string(s.AuthRolesClaim),
+		DefaultRole: p.role("AUTH_DEFAULT_ROLE", s.AuthDefaultRole),
+		APIKeys:     p.apiKeys("API_KEYS", s.APIKeys),
+	}
+
+	if a.Disabled {
+		return a
+	}
+
+	if a.Server == "" && strings.TrimSpace(string(s.APIKeys)) == "" {
+		p.fail("AUTH_SERVER", "AUTH_SERVER or API_KEYS is required, set AUTH_DISABLED=true to run without authentication")
+	}
+
+	if a.Server != "" {
+		// the jwks url is appended to the server url
+		if !strings.HasSuffix(a.Server, "/") {
+			p.fail("AUTH_SERVER", "must end with /, got [%s]", a.Server)
+		}
+		p.url("AUTH_SERVER", s.AuthServer)
+		p.required("AUTH_AUDIENCE", s.AuthAudience)
+	}
+
+	return a
+}
+
+func (p *parser) cors(s source) cors.Options {
+	o := cors.Options{
+		AllowedOrigins:   list(s.CORSAllowedOrigins),
+		AllowedMethods:   list(s.CORSAllowedMethods),
+		AllowedHeaders:   list(s.CORSAllowedHeaders),
+		ExposedHeaders:   list(s.CORSExposedHeaders),
+		AllowCredentials: p.bool("CORS_ALLOW_CREDENTIALS", s.CORSAllowCredentials),
+		MaxAge:           p.duration("CORS_MAX_AGE", s.CORSMaxAge),
+	}
+
+	for _, origin := range o.AllowedOrigins {
+		if origin == "*" {
+			// browsers refuse credentials for every origin
+			if o.AllowCredentials {
+				p.fail("CORS_ALLOWED_ORIGINS", "[*] can't be used with CORS_ALLOW_CREDENTIALS, list the origins")
+			}
+			continue
+		}
+
+		u, err := url.Parse(strings.Replace(origin, "*.", "", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			p.fail("CORS_ALLOWED_ORIGINS", "invalid origin [%s], use scheme://host[:port]", origin)
+		}
+	}
+
+	return o
+}
+
+// list splits a comma separated setting
+func list(value setting) []string {
+	result := []string{}
+
+	for _, item := range strings.Split(string(value), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func (p *parser) role(name string, value setting) auth.Role {
+	role, err := auth.ParseRole(string(value))
+	if err != nil {
+		p.fail(name, "%v", err)
+	}
+
+	return role
+}
+
+// apiKeys reads a comma separated list of name:role:key entries
+func (p *parser) apiKeys(name string, value setting) []auth.StaticKey {
+	keys := []auth.StaticKey{}
+
+	for _, entry := range strings.Split(string(value), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			p.fail(name, "entries must be name:role:key")
+			continue
+		}
+
+		role, err := auth.ParseRole(parts[1])
+		if err != nil {
+			p.fail(name, "key [%s]: %v", parts[0], err)
+			continue
+		}
+
+		keys = append(keys, auth.StaticKey{Name: parts[0], Role: role, Key: parts[2]})
+	}
+
+	return keys
+}
+
+func (p *parser) rateLimit(s source) RateLimit {
+	r := RateLimit{
+		ReadPerSecond:      p.limit("RATE_LIMIT_READ", s.RateLimitRead),
+		ReadBurst:          p.count("RATE_LIMIT_READ_BURST", s.RateLimitReadBurst),
+		ProviderPerSecond:  p.limit("RATE_LIMIT_PROVIDER", s.RateLimitProvider),
+		ProviderBurst:      p.count("RATE_LIMIT_PROVIDER_BURST", s.RateLimitProviderBurst),
+		TrustForwarded:     p.bool("RATE_LIMIT_TRUST_PROXY", s.RateLimitTrustProxy),
+		DailyRegistrations: p.count("DAILY_REGISTRATION_QUOTA", s.DailyRegistrationQuota),
+	}
+
+	// an empty bucket would reject every request
+	if r.ReadPerSecond > 0 && r.ReadBurst < 1 {
+		p.fail("RATE_LIMIT_READ_BURST", "must be at least 1 when RATE_LIMIT_READ is set")
+	}
+	if r.ProviderPerSecond > 0 && r.ProviderBurst < 1 {
+		p.fail("RATE_LIMIT_PROVIDER_BURST", "must be at least 1 when RATE_LIMIT_PROVIDER is set")
+	}
+
+	return r
+}
+
+func (p *parser) count(name string, value setting) int {
+	n, err := strconv.Atoi(string(value))
+	if err != nil || n < 0 {
+		p.fail(name, "invalid count [%s], must not be negative", value)
+	}
+
+	return n
+}
+
+func (p *parser) bool(name string, value setting) bool {
+	b, err := strconv.ParseBool(string(value))
+	if err != nil {
+		p.fail(name, "invalid boolean [%s]", value)
+	}
+
+	return b
+}
+
+func (p *parser) limit(name string, value setting) float64 {
+	f, err := strconv.ParseFloat(string(value), 64)
+	if err != nil {
+		p.fail(name, "invalid number [%s]", value)
+		return 0
+	}
+
+	if f < 0 {
+		p.fail(name, "must not be negative, 0 disables the check")
+	}
+
+	return f
+}
+
+func formatLimit(f float64) setting {
+	return setting(strconv.FormatFloat(f, 'f', -1, 64))
+}
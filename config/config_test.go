@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nagymarci/stock-screener/auth"
+)
+
+func setRequired(t *testing.T) {
+	t.Setenv("DB_CONNECTION_URI", "mongodb://localhost:27017")
+	t.Setenv("STOCKINFO_PROVIDER_URL", "http://localhost:8081/stockinfo/")
+	t.Setenv("PORT", "8080")
+	t.Setenv("STOCK_UPDATE_INTERVAL", "1h")
+	t.Setenv("PE_UPDATE_INTERVAL", "168h")
+	t.Setenv("DIV_UPDATE_INTERVAL", "168h")
+	t.Setenv("API_KEYS", "profile:viewer:secret")
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("reads the environment with defaults", func(t *testing.T) {
+		setRequired(t)
+
+		c, err := Load(nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.Port != 8080 || c.Intervals.PeRatio != 168*time.Hour || c.Intervals.HistoryJitter != 24*time.Hour {
+			t.Fatalf("unexpected config [%+v]", c)
+		}
+
+		if c.StaleThreshold != 72*time.Hour || c.ShutdownDrainDelay != 5*time.Second || c.Validation.MaxPrice != 1000000 {
+			t.Fatalf("defaults are not applied [%+v]", c)
+		}
+
+		if len(c.Auth.APIKeys) != 1 || c.Auth.APIKeys[0].Name != "profile" || c.Auth.APIKeys[0].Role != auth.RoleViewer {
+			t.Fatalf("unexpected api keys [%+v]", c.Auth.APIKeys)
+		}
+	})
+
+	t.Run("file is overridden by the environment and flags", func(t *testing.T) {
+		setRequired(t)
+		t.Setenv("PORT", "")
+		t.Setenv("STALE_THRESHOLD", "48h")
+
+		file := filepath.Join(t.TempDir(), "config.yaml")
+		content := "port: 9090\nstaleThreshold: 24h\nupdateCron: \"0 * * * *\"\nvalidationMaxPrice: 500\n"
+		if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := Load([]string{"-config", file, "-updateCron", "*/5 * * * *"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.Port != 9090 || c.Validation.MaxPrice != 500 {
+			t.Fatalf("file is not applied [%+v]", c)
+		}
+
+		if c.StaleThreshold != 48*time.Hour {
+			t.Fatalf("expected environment to override the file, got [%v]", c.StaleThreshold)
+		}
+
+		if c.CronSpec != "*/5 * * * *" {
+			t.Fatalf("expected flag to override the file, got [%s]", c.CronSpec)
+		}
+	})
+
+	t.Run("reports every invalid setting", func(t *testing.T) {
+		setRequired(t)
+		t.Setenv("STOCK_UPDATE_INTERVAL", "1hour")
+		t.Setenv("PE_UPDATE_INTERVAL", "0s")
+		t.Setenv("PORT", "80800")
+		t.Setenv("STOCKINFO_PROVIDER_URL", "localhost:8081")
+		t.Setenv("UPDATE_CRON", "every hour")
+		t.Setenv("API_KEYS", "profile:owner:secret")
+
+		_, err := Load(nil)
+
+		configErr, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("expected config error, got [%v]", err)
+		}
+
+		for _, name := range []string{"STOCK_UPDATE_INTERVAL", "PE_UPDATE_INTERVAL", "PORT", "STOCKINFO_PROVIDER_URL", "UPDATE_CRON", "API_KEYS"} {
+			if !strings.Contains(configErr.Error(), name+":") {
+				t.Errorf("[%s] is not reported in [%v]", name, configErr)
+			}
+		}
+
+		if len(configErr.Problems) != 6 {
+			t.Fatalf("expected 6 problems, got %v", configErr.Problems)
+		}
+	})
+
+	t.Run("rejects credentials for every origin", func(t *testing.T) {
+		setRequired(t)
+		t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+		t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+
+		_, err := Load(nil)
+
+		configErr, ok := err.(*Error)
+		if !ok || len(configErr.Problems) != 1 || !strings.Contains(configErr.Error(), "CORS_ALLOWED_ORIGINS:") {
+			t.Fatalf("expected the origins to be reported, got [%v]", err)
+		}
+	})
+	t.Run("rejects a drain delay as long as the shutdown timeout", func(t *testing.T) {
+		setRequired(t)
+		t.Setenv("SHUTDOWN_TIMEOUT", "10s")
+		t.Setenv("SHUTDOWN_DRAIN_DELAY", "10s")
+
+		_, err := Load(nil)
+
+		configErr, ok := err.(*Error)
+		if !ok || len(configErr.Problems) != 1 || !strings.Contains(configErr.Error(), "SHUTDOWN_DRAIN_DELAY:") {
+			t.Fatalf("expected the drain delay to be reported, got [%v]", err)
+		}
+	})
+}
@@ -1,70 +1,346 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
 	"github.com/nagymarci/stock-screener/api"
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/events"
+	"github.com/nagymarci/stock-screener/importer"
 	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+	"github.com/nagymarci/stock-screener/ratelimit"
+	"github.com/nagymarci/stock-screener/service"
+	"github.com/nagymarci/stock-screener/tracing"
+	"github.com/nagymarci/stock-screener/watch"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/nagymarci/stock-screener/database"
-
-	stockHttp "github.com/nagymarci/stock-commons/http"
 )
 
 type Controller struct {
-	database *database.Stockinfos
-	client   *api.StockScraper
+	database       *database.Stockinfos
+	quarantine     *database.Quarantine
+	client         *api.StockScraper
+	staleThreshold time.Duration
+	registrations  *ratelimit.Quota
+	changes        *watch.Hub
+	publisher      *events.Publisher
+	// schedules the next provider update of the written stocks
+	updater *service.Updater
 }
 
-func New(db *database.Stockinfos, cl *api.StockScraper) *Controller {
+func New(db *database.Stockinfos, quarantine *database.Quarantine, cl *api.StockScraper, staleThreshold time.Duration, registrations *ratelimit.Quota, changes *watch.Hub, publisher *events.Publisher, updater *service.Updater) *Controller {
 	return &Controller{
-		database: db,
-		client:   cl,
+		database:       db,
+		quarantine:     quarantine,
+		client:         cl,
+		staleThreshold: staleThreshold,
+		registrations:  registrations,
+		changes:        changes,
+		publisher:      publisher,
+		updater:        updater,
 	}
 }
 
 // RegisterStock registers a stock symbol to the watchlist to evaluate it
-func (c *Controller) RegisterStock(symbol string) error {
-	_, err := c.database.Get(symbol)
+func (c *Controller) RegisterStock(ctx context.Context, symbol model.Symbol) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.RegisterStock", attribute.String("symbol", symbol.String()))
+	defer tracing.End(span, &err)
+
+	_, err = c.database.Get(ctx, symbol)
 
 	if err == nil {
 		return nil
 	}
 
-	stockData, err := c.client.Get(symbol)
+	// only new stocks count against the quota, they are the ones calling the provider
+	release, err := c.registrations.Take(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	stockData, err := c.client.Get(ctx, symbol.Provider())
+
+	if errors.Is(err, api.ErrUnknownSymbol) {
+		release()
+		return problem.Wrap(http.StatusUnprocessableEntity, problem.CodeUnknownSymbol, err)
+	}
 
 	if err != nil {
-		return stockHttp.NewFailedDependencyError(err.Error())
+		release()
+		return providerUnavailable(symbol, err)
 	}
 
-	err = c.database.Save(stockData)
+	// the provider may answer with its own notation of the ticker
+	stockData.Ticker = symbol.String()
+
+	stockData.SetProvenance(time.Now(), model.SourceProvider)
+
+	err = c.publisher.Record(ctx, func(ctx context.Context) ([]model.Event, error) {
+		if err := c.database.Save(ctx, stockData, manual(ctx, model.CauseRegistration)); err != nil {
+			return nil, err
+		}
+
+		return []model.Event{events.Registered(stockData)}, nil
+	})
 
 	if err != nil {
-		return stockHttp.NewInternalServerError(err.Error())
+		release()
+		return problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
 	}
 
+	c.changes.Publish(watch.KindRegistered, stockData)
+
 	return nil
 }
 
 // GetStockInfo returns the information of a stock symbol
-func (c *Controller) GetStockInfo(symbol string) (model.StockDataInfo, error) {
-	result, err := c.database.Get(symbol)
+func (c *Controller) GetStockInfo(ctx context.Context, symbol model.Symbol) (result model.StockDataInfo, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.GetStockInfo", attribute.String("symbol", symbol.String()))
+	defer tracing.End(span, &err)
+
+	result, err = c.database.Get(ctx, symbol)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return result, problem.New(http.StatusNotFound, problem.CodeStockNotFound, "stock ["+symbol.String()+"] is not registered")
+	}
 
 	if err != nil {
-		return result, stockHttp.NewNotFoundError(err.Error())
+		return result, problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
 	}
 
+	c.present(&result, time.Now())
+
 	return result, nil
 }
 
-// GetAllStocks returns the information of all of the stocks
-func (c *Controller) GetAllStocks() []model.StockDataInfo {
-	result, err := c.database.GetAll()
+// GetChanges returns the audit trail of the stock, newest first, an empty field returns every field
+func (c *Controller) GetChanges(ctx context.Context, symbol model.Symbol, field string, limit int) (result []model.StockChange, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.GetChanges", attribute.String("symbol", symbol.String()))
+	defer tracing.End(span, &err)
+
+	result, err = c.database.Changes(ctx, symbol, field, limit)
+
+	if err != nil {
+		return result, problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
+	}
+
+	if len(result) > 0 {
+		return result, nil
+	}
+
+	// deleted stocks keep their trail, so only the unknown ones without changes are missing
+	_, err = c.database.Get(ctx, symbol)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return result, problem.New(http.StatusNotFound, problem.CodeStockNotFound, "stock ["+symbol.String()+"] is not registered")
+	}
+
+	if err != nil {
+		return result, problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
+	}
+
+	return result, nil
+}
+
+// GetStocks returns the stocks of the symbols with one database query, unknown symbols are left out
+func (c *Controller) GetStocks(ctx context.Context, symbols []model.Symbol) (result []model.StockDataInfo, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.GetStocks", attribute.Int("symbols", len(symbols)))
+	defer tracing.End(span, &err)
+
+	result, err = c.database.GetMany(ctx, symbols)
+
+	if err != nil {
+		return result, problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
+	}
+
+	now := time.Now()
+	for i := range result {
+		c.present(&result[i], now)
+	}
+
+	return result, nil
+}
+
+// GetAllStocks returns the page of stocks selected by the query
+func (c *Controller) GetAllStocks(ctx context.Context, query database.Query) (result database.Page, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.GetAllStocks")
+	defer tracing.End(span, &err)
+
+	result, err = c.database.Find(ctx, query)
+
+	if err == database.ErrInvalidCursor {
+		return result, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidCursor, err)
+	}
 
 	if err != nil {
 		logrus.Warnln(err)
+		return result, problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
+	}
+
+	now := time.Now()
+	for i := range result.Stocks {
+		c.present(&result.Stocks[i], now)
+	}
+
+	return result, nil
+}
+
+// ExportStocks passes every stock selected by the query to the write function without loading all of them
+func (c *Controller) ExportStocks(ctx context.Context, query database.Query, write func(model.StockDataInfo) error) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.ExportStocks")
+	defer tracing.End(span, &err)
+
+	now := time.Now()
+	err = c.database.Stream(ctx, query, func(stock model.StockDataInfo) error {
+		c.present(&stock, now)
+		return write(stock)
+	})
+
+	if err == database.ErrInvalidCursor {
+		return problem.Wrap(http.StatusBadRequest, problem.CodeInvalidCursor, err)
+	}
+
+	return err
+}
+
+// errEnough stops the stream once the screen has found enough stocks
+var errEnough = errors.New("enough stocks")
+
+// ScreenStocks returns the first limit stocks matching the screen in the order of the query, 0 means no limit
+func (c *Controller) ScreenStocks(ctx context.Context, query database.Query, match func(model.StockDataInfo) bool, limit int) (result []model.StockDataInfo, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.ScreenStocks", attribute.Int("limit", limit))
+	defer tracing.End(span, &err)
+
+	result = []model.StockDataInfo{}
+
+	err = c.ExportStocks(ctx, query, func(stock model.StockDataInfo) error {
+		if !match(stock) {
+			return nil
+		}
+
+		result = append(result, stock)
+
+		if limit > 0 && len(result) == limit {
+			return errEnough
+		}
+		return nil
+	})
+
+	if errors.Is(err, errEnough) {
+		return result, nil
+	}
+
+	return result, err
+}
+
+// ImportStocks registers the tickers or stores the stock data of the import, nothing is written on dry run
+func (c *Controller) ImportStocks(ctx context.Context, data importer.Import, dryRun bool) importer.Report {
+	ctx, span := tracing.Start(ctx, "Controller.ImportStocks", attribute.Int("rows", len(data.Rows)), attribute.Bool("dryRun", dryRun))
+	defer span.End()
+
+	report := importer.Report{
+		DryRun: dryRun,
+		Rows:   len(data.Rows) + len(data.Errors),
+		Errors: data.Errors,
+	}
+
+	for _, row := range data.Rows {
+		var err error
+		switch {
+		case dryRun && data.TickersOnly:
+			err = c.checkImported(ctx, row.Stock.Ticker)
+		case dryRun:
+			// the stock data is validated by the parser
+		case data.TickersOnly:
+			err = c.registerImported(ctx, row.Stock.Ticker)
+		default:
+			c.updater.Schedule(&row.Stock)
+
+			var stored model.StockDataInfo
+			err = c.publisher.Record(ctx, func(ctx context.Context) ([]model.Event, error) {
+				var err error
+				if stored, err = c.database.Upsert(ctx, row.Stock, row.Fields, model.SourceImport, manual(ctx, model.CauseImport)); err != nil {
+					return nil, err
+				}
+
+				return events.Written(stored, row.Fields), nil
+			})
+			if err == nil {
+				c.changes.Publish(watch.KindUpdated, stored)
+			}
+		}
+
+		if err != nil {
+			report.Errors = append(report.Errors, importer.RowError{Row: row.Number, Ticker: row.Stock.Ticker, Message: err.Error()})
+			continue
+		}
+
+		report.Imported++
+	}
+
+	if report.Errors == nil {
+		report.Errors = []importer.RowError{}
+	}
+
+	sort.Slice(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Row < report.Errors[j].Row
+	})
+
+	return report
+}
+
+// checkImported tells if the ticker the importer has already normalised could be registered, without writing it
+func (c *Controller) checkImported(ctx context.Context, ticker string) error {
+	symbol, err := model.ParseSymbol(ticker)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = c.database.Get(ctx, symbol)
+
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	_, err = c.client.Get(ctx, symbol.Provider())
+
+	if err != nil && !errors.Is(err, api.ErrUnknownSymbol) {
+		return providerUnavailable(symbol, err)
 	}
 
-	return result
+	return err
+}
+
+// providerUnavailable logs the failed provider call, the callers get a fixed detail since the error carries the provider url
+func providerUnavailable(symbol model.Symbol, err error) error {
+	logrus.WithField("symbol", symbol).Errorf("Failed to get the stock from the provider: %v\n", err)
+
+	return problem.New(http.StatusBadGateway, problem.CodeProviderUnavailable, "stock data provider is unavailable")
+}
+
+// registerImported registers a ticker the importer has already normalised
+func (c *Controller) registerImported(ctx context.Context, ticker string) error {
+	symbol, err := model.ParseSymbol(ticker)
+
+	if err != nil {
+		return err
+	}
+
+	return c.RegisterStock(ctx, symbol)
 }
 
 /*
@@ -77,12 +353,181 @@ func UpdateAll() {
 }*/
 
 //DeleteStock deletes the given stock from the database
-func (c *Controller) DeleteStock(symbol string) error {
-	err := c.database.Delete(symbol)
+func (c *Controller) DeleteStock(ctx context.Context, symbol model.Symbol) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.DeleteStock", attribute.String("symbol", symbol.String()))
+	defer tracing.End(span, &err)
+
+	err = c.publisher.Record(ctx, func(ctx context.Context) ([]model.Event, error) {
+		if err := c.database.Delete(ctx, symbol); err != nil {
+			return nil, err
+		}
+
+		// a pending update approved later would register the stock again
+		if err := c.quarantine.Delete(ctx, symbol); err != nil {
+			return nil, err
+		}
+
+		return []model.Event{events.Deleted(symbol.String())}, nil
+	})
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return problem.New(http.StatusNotFound, problem.CodeStockNotFound, "stock ["+symbol.String()+"] is not registered")
+	}
+
+	if err != nil {
+		return problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
+	}
+
+	c.changes.Publish(watch.KindDeleted, model.StockDataInfo{Ticker: symbol.String()})
+
+	return nil
+}
+
+// GetQuarantined returns the provider updates waiting for review
+func (c *Controller) GetQuarantined(ctx context.Context) (result []model.QuarantineEntry, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.GetQuarantined")
+	defer tracing.End(span, &err)
+
+	result, err = c.quarantine.GetAll(ctx)
+
+	if err != nil {
+		return result, problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
+	}
+
+	return result, nil
+}
+
+// GetQuarantinedOf returns the pending updates of the symbols by ticker, with one database query
+func (c *Controller) GetQuarantinedOf(ctx context.Context, symbols []model.Symbol) (result map[string]model.QuarantineEntry, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.GetQuarantinedOf", attribute.Int("symbols", len(symbols)))
+	defer tracing.End(span, &err)
+
+	entries, err := c.quarantine.GetMany(ctx, symbols)
+
+	if err != nil {
+		return nil, problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
+	}
+
+	result = make(map[string]model.QuarantineEntry, len(entries))
+	for _, entry := range entries {
+		result[entry.Ticker] = entry
+	}
+
+	return result, nil
+}
+
+// ApproveQuarantined applies the quarantined update of the symbol
+func (c *Controller) ApproveQuarantined(ctx context.Context, symbol model.Symbol) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.ApproveQuarantined", attribute.String("symbol", symbol.String()))
+	defer tracing.End(span, &err)
+
+	entry, err := c.quarantine.Get(ctx, symbol)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return problem.New(http.StatusNotFound, problem.CodeQuarantineNotFound, "no quarantined update of ["+symbol.String()+"]")
+	}
+
+	if err != nil {
+		return problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
+	}
+
+	var stored model.StockDataInfo
+	err = c.publisher.Record(ctx, func(ctx context.Context) ([]model.Event, error) {
+		var err error
+		if stored, err = c.database.Update(ctx, entry.Proposed, entry.Fields, manual(ctx, model.CauseApproval)); err != nil {
+			return nil, err
+		}
+
+		return events.Updated(stored, entry.Fields), nil
+	})
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return problem.New(http.StatusNotFound, problem.CodeStockNotFound, "stock ["+symbol.String()+"] is not registered")
+	}
 
 	if err != nil {
-		return stockHttp.NewInternalServerError(err.Error())
+		return problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
+	}
+
+	c.changes.Publish(watch.KindUpdated, stored)
+
+	return c.RejectQuarantined(ctx, symbol)
+}
+
+// RejectQuarantined drops the quarantined update of the symbol
+func (c *Controller) RejectQuarantined(ctx context.Context, symbol model.Symbol) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.RejectQuarantined", attribute.String("symbol", symbol.String()))
+	defer tracing.End(span, &err)
+
+	err = c.quarantine.Delete(ctx, symbol)
+
+	if err != nil {
+		return problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
 	}
 
 	return nil
 }
+
+// SetOverrides replaces the overrides of the symbol, the updater keeps the pinned values until they expire
+func (c *Controller) SetOverrides(ctx context.Context, symbol model.Symbol, overrides []model.Override) (result model.StockDataInfo, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.SetOverrides", attribute.String("symbol", symbol.String()), attribute.Int("overrides", len(overrides)))
+	defer tracing.End(span, &err)
+
+	now := time.Now()
+	if err := model.ValidateOverrides(overrides, now); err != nil {
+		return result, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidOverride, err)
+	}
+
+	cause := manual(ctx, model.CauseOverride)
+	fields := make([]model.Field, 0, len(overrides))
+	for i := range overrides {
+		overrides[i].Created = now
+		overrides[i].CreatedBy = cause.Actor
+
+		f, _ := model.LookupField(overrides[i].Field)
+		fields = append(fields, f)
+	}
+
+	if overrides == nil {
+		overrides = []model.Override{}
+	}
+
+	err = c.publisher.Record(ctx, func(ctx context.Context) ([]model.Event, error) {
+		var err error
+		if result, err = c.database.Override(ctx, symbol, overrides, cause); err != nil {
+			return nil, err
+		}
+
+		return events.Written(result, fields), nil
+	})
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return result, problem.New(http.StatusNotFound, problem.CodeStockNotFound, "stock ["+symbol.String()+"] is not registered")
+	}
+
+	if err != nil {
+		return result, problem.Wrap(http.StatusInternalServerError, problem.CodeInternal, err)
+	}
+
+	c.changes.Publish(watch.KindUpdated, result)
+	c.present(&result, now)
+
+	return result, nil
+}
+
+// present prepares the stored stock for the response, the stale values are flagged and the expired overrides left out
+func (c *Controller) present(stock *model.StockDataInfo, now time.Time) {
+	stock.UpdateStale(now, c.staleThreshold)
+	stock.PruneOverrides(now)
+}
+
+// manual returns the cause of a write requested by the caller
+func manual(ctx context.Context, kind string) model.Cause {
+	cause := model.Cause{Kind: kind}
+
+	if p, ok := auth.FromContext(ctx); ok {
+		cause.Actor = p.Subject
+	}
+
+	return cause
+}
@@ -0,0 +1,157 @@
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Options configures which browser origins may call the API
+type Options struct {
+	// "*" allows every origin, "https://*.example.com" every subdomain
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+//CORS answers preflight requests and adds the CORS headers for allowed origins.
+//It runs in front of the router, which would reject the OPTIONS method of most routes.
+type CORS struct {
+	options Options
+	methods map[string]bool
+	headers map[string]bool
+}
+
+func New(options Options) *CORS {
+	c := &CORS{
+		options: options,
+		methods: make(map[string]bool),
+		headers: make(map[string]bool),
+	}
+
+	for _, method := range options.AllowedMethods {
+		c.methods[strings.ToUpper(method)] = true
+	}
+	for _, header := range options.AllowedHeaders {
+		c.headers[http.CanonicalHeaderKey(header)] = true
+	}
+
+	return c
+}
+
+func (c *CORS) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	origin := r.Header.Get("Origin")
+
+	if origin == "" {
+		next(w, r)
+		return
+	}
+
+	w.Header().Add("Vary", "Origin")
+
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		c.preflight(w, r, origin)
+		return
+	}
+
+	if c.OriginAllowed(origin) {
+		c.setOrigin(w, origin)
+		if len(c.options.ExposedHeaders) > 0 {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.options.ExposedHeaders, ", "))
+		}
+	}
+
+	next(w, r)
+}
+
+// preflight answers without calling the routes, the headers are left out if the request isn't allowed
+func (c *CORS) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	requested := requestedHeaders(r)
+
+	if !c.OriginAllowed(origin) || !c.methods[method] || !c.headersAllowed(requested) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	c.setOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.options.AllowedMethods, ", "))
+	if len(requested) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+	if c.options.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.options.MaxAge.Seconds())))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *CORS) setOrigin(w http.ResponseWriter, origin string) {
+	// the wildcard can't be used with credentials, the origin is echoed instead
+	if c.allowsAll() && !c.options.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if c.options.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *CORS) allowsAll() bool {
+	for _, allowed := range c.options.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+//OriginAllowed tells if browsers on the origin may call the API
+func (c *CORS) OriginAllowed(origin string) bool {
+	for _, allowed := range c.options.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+
+		if i := strings.Index(allowed, "*."); i >= 0 {
+			prefix, suffix := allowed[:i], allowed[i+1:]
+			if len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *CORS) headersAllowed(requested []string) bool {
+	for _, header := range requested {
+		if !c.headers[header] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func requestedHeaders(r *http.Request) []string {
+	result := []string{}
+
+	for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		if header = strings.TrimSpace(header); header != "" {
+			result = append(result, http.CanonicalHeaderKey(header))
+		}
+	}
+
+	return result
+}
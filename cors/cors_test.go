@@ -0,0 +1,77 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serve(c *CORS, r *http.Request) (*httptest.ResponseRecorder, bool) {
+	rec := httptest.NewRecorder()
+	called := false
+
+	c.ServeHTTP(rec, r, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	return rec, called
+}
+
+func TestCORS(t *testing.T) {
+	c := New(Options{
+		AllowedOrigins:   []string{"https://dashboard.example.com", "https://*.preview.example.com"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodDelete},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"Link"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+
+	t.Run("answers preflight of an allowed origin", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodOptions, "/stocks/INTC", nil)
+		r.Header.Set("Origin", "https://dashboard.example.com")
+		r.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+		r.Header.Set("Access-Control-Request-Headers", "authorization")
+
+		rec, called := serve(c, r)
+
+		if called || rec.Code != http.StatusNoContent {
+			t.Fatalf("expected preflight to be answered, got [%d] called [%v]", rec.Code, called)
+		}
+
+		if rec.Header().Get("Access-Control-Allow-Origin") != "https://dashboard.example.com" ||
+			rec.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+			rec.Header().Get("Access-Control-Allow-Headers") != "Authorization" ||
+			rec.Header().Get("Access-Control-Max-Age") != "600" {
+			t.Fatalf("unexpected headers [%v]", rec.Header())
+		}
+	})
+
+	t.Run("preflight of an unknown origin gets no headers", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodOptions, "/stocks", nil)
+		r.Header.Set("Origin", "https://evil.example.org")
+		r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+		rec, _ := serve(c, r)
+
+		if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Fatalf("unexpected allow origin [%s]", rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+	})
+
+	t.Run("adds headers to requests of subdomain wildcard origins", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/stocks", nil)
+		r.Header.Set("Origin", "https://pr-12.preview.example.com")
+
+		rec, called := serve(c, r)
+
+		if !called {
+			t.Fatal("expected request to reach the routes")
+		}
+
+		if rec.Header().Get("Access-Control-Allow-Origin") != "https://pr-12.preview.example.com" || rec.Header().Get("Access-Control-Expose-Headers") != "Link" {
+			t.Fatalf("unexpected headers [%v]", rec.Header())
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/nagymarci/stock-screener/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeys struct {
+	collection *mongo.Collection
+}
+
+func NewAPIKeys(db *mongo.Database) *APIKeys {
+	return &APIKeys{
+		collection: db.Collection("apikeys"),
+	}
+}
+
+//Save stores the new key
+func (k *APIKeys) Save(ctx context.Context, key model.APIKey) error {
+	_, err := k.collection.InsertOne(ctx, key)
+
+	return err
+}
+
+//Get retreives the key by id
+func (k *APIKeys) Get(ctx context.Context, id string) (model.APIKey, error) {
+	var result model.APIKey
+
+	err := k.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&result)
+
+	return result, err
+}
+
+//GetAll retreives every key, newest first
+func (k *APIKeys) GetAll(ctx context.Context) ([]model.APIKey, error) {
+	cursor, err := k.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created", Value: -1}}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []model.APIKey{}
+
+	err = cursor.All(ctx, &result)
+
+	return result, err
+}
+
+//Revoke marks the key revoked, mongo.ErrNoDocuments is returned for unknown or already revoked keys
+func (k *APIKeys) Revoke(ctx context.Context, id string, revoked time.Time) error {
+	return k.set(ctx, bson.D{{Key: "_id", Value: id}, {Key: "revoked", Value: bson.M{"$exists": false}}}, bson.M{"revoked": revoked})
+}
+
+//Expire sets the expiry of the key
+func (k *APIKeys) Expire(ctx context.Context, id string, expires time.Time) error {
+	return k.set(ctx, bson.D{{Key: "_id", Value: id}}, bson.M{"expires": expires})
+}
+
+//Touch records the use of the key, it's only written once in the resolution to spare the writes
+func (k *APIKeys) Touch(ctx context.Context, id string, used time.Time, resolution time.Duration) error {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "$or", Value: bson.A{
+			bson.M{"lastUsed": bson.M{"$exists": false}},
+			bson.M{"lastUsed": bson.M{"$lt": used.Add(-resolution)}},
+		}},
+	}
+
+	_, err := k.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lastUsed": used}})
+
+	return err
+}
+
+func (k *APIKeys) set(ctx context.Context, filter bson.D, fields bson.M) error {
+	result, err := k.collection.UpdateOne(ctx, filter, bson.M{"$set": fields})
+
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
@@ -40,3 +40,13 @@ func New(connectionURI string) *mongo.Database {
 
 	return database
 }
+
+//Ping checks that the primary of the database is reachable
+func Ping(ctx context.Context, db *mongo.Database) error {
+	return db.Client().Ping(ctx, readpref.Primary())
+}
+
+//Disconnect closes the connections of the database client
+func Disconnect(ctx context.Context, db *mongo.Database) error {
+	return db.Client().Disconnect(ctx)
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// standalone servers reject transactions with IllegalOperation
+const illegalOperationCode = 20
+
+//Outbox keeps the events written with the changes they report until they are dispatched
+type Outbox struct {
+	collection *mongo.Collection
+	// set once the server turned out to be a standalone one
+	standalone atomic.Bool
+}
+
+func NewOutbox(db *mongo.Database) *Outbox {
+	return &Outbox{
+		collection: db.Collection("outbox"),
+	}
+}
+
+//Record runs the write and stores the events it returns in one transaction, so an event is stored if and only if its change is.
+//Standalone servers don't support transactions, there the events are stored after the write.
+func (o *Outbox) Record(ctx context.Context, write func(ctx context.Context) ([]model.Event, error)) error {
+	if !o.standalone.Load() {
+		err := o.transaction(ctx, write)
+
+		if !isIllegalOperation(err) {
+			return err
+		}
+
+		o.standalone.Store(true)
+		logrus.Warnln("Transactions are not supported by the database, events may be lost when a write fails, run mongo as a replica set")
+	}
+
+	events, err := write(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return o.add(ctx, events)
+}
+
+func (o *Outbox) transaction(ctx context.Context, write func(ctx context.Context) ([]model.Event, error)) error {
+	session, err := o.collection.Database().Client().StartSession()
+
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		events, err := write(sessionCtx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, o.add(sessionCtx, events)
+	})
+
+	return err
+}
+
+func (o *Outbox) add(ctx context.Context, events []model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		documents = append(documents, event)
+	}
+
+	_, err := o.collection.InsertMany(ctx, documents)
+
+	return err
+}
+
+//Pending returns the oldest events up to the limit
+func (o *Outbox) Pending(ctx context.Context, limit int) ([]model.Event, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit))
+
+	cursor, err := o.collection.Find(ctx, bson.M{}, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []model.Event{}
+
+	err = cursor.All(ctx, &result)
+
+	return result, err
+}
+
+//Remove deletes the dispatched event
+func (o *Outbox) Remove(ctx context.Context, id string) error {
+	_, err := o.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+
+	return err
+}
+
+func isIllegalOperation(err error) bool {
+	var commandErr mongo.CommandError
+	return errors.As(err, &commandErr) && commandErr.Code == illegalOperationCode
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+
+	"github.com/nagymarci/stock-screener/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Quarantine struct {
+	collection *mongo.Collection
+}
+
+func NewQuarantine(db *mongo.Database) *Quarantine {
+	return &Quarantine{
+		collection: db.Collection("quarantine"),
+	}
+}
+
+//Save stores the entry, replacing the update of the pending entry of the same ticker.
+//The pending entry keeps its creation time, so the review order doesn't change.
+func (q *Quarantine) Save(ctx context.Context, entry model.QuarantineEntry) error {
+	filter := bson.D{{Key: "ticker", Value: entry.Ticker}}
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "fields", Value: entry.Fields},
+			{Key: "previous", Value: entry.Previous},
+			{Key: "proposed", Value: entry.Proposed},
+			{Key: "violations", Value: entry.Violations}}},
+		{Key: "$setOnInsert", Value: bson.D{{Key: "created", Value: entry.Created}}}}
+
+	_, err := q.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+
+	return err
+}
+
+//Get retreives the pending entry of the ticker
+func (q *Quarantine) Get(ctx context.Context, symbol model.Symbol) (model.QuarantineEntry, error) {
+	var result model.QuarantineEntry
+
+	filter := bson.D{{Key: "ticker", Value: symbol.String()}}
+
+	err := q.collection.FindOne(ctx, filter).Decode(&result)
+
+	return result, err
+}
+
+//GetMany retreives the pending entries of the symbols with one query
+func (q *Quarantine) GetMany(ctx context.Context, symbols []model.Symbol) ([]model.QuarantineEntry, error) {
+	result := []model.QuarantineEntry{}
+
+	cursor, err := q.collection.Find(ctx, bson.D{{Key: "ticker", Value: bson.D{{Key: "$in", Value: tickers(symbols)}}}})
+
+	if err != nil {
+		return result, err
+	}
+
+	err = cursor.All(ctx, &result)
+
+	return result, err
+}
+
+//GetAll retreives every pending entry, oldest first
+func (q *Quarantine) GetAll(ctx context.Context) ([]model.QuarantineEntry, error) {
+	cursor, err := q.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created", Value: 1}}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []model.QuarantineEntry{}
+
+	err = cursor.All(ctx, &result)
+
+	return result, err
+}
+
+//Delete removes the pending entry of the ticker
+func (q *Quarantine) Delete(ctx context.Context, symbol model.Symbol) error {
+	filter := bson.D{{Key: "ticker", Value: symbol.String()}}
+
+	_, err := q.collection.DeleteOne(ctx, filter)
+
+	return err
+}
+
+//MigrateSymbols rewrites the tickers stored before symbols were normalised to the canonical form,
+//of duplicate entries the newest is kept. It returns the number of migrated and dropped entries.
+func (q *Quarantine) MigrateSymbols(ctx context.Context) (int, int, error) {
+	return migrateSymbols(ctx, q.collection, "created")
+}
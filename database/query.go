@@ -0,0 +1,299 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/tracing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	sortValueKey   = "_sortValue"
+	sortMissingKey = "_sortMissing"
+)
+
+//ErrInvalidCursor is returned when the page cursor can't be used with the query
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+//Query describes a request for a page of stocks
+type Query struct {
+	//Limit is the maximum number of stocks on the page, 0 means no limit
+	Limit int
+	//Cursor is the opaque position returned with the previous page
+	Cursor string
+	//SortField is the field to order by, the ticker is used when it's empty
+	SortField model.Field
+	//Descending reverses the order of the sort field
+	Descending bool
+	//Fields limits the fields loaded from the database, everything is loaded when it's empty
+	Fields []model.Field
+}
+
+//Page is one page of stocks with the cursor pointing to the next page
+type Page struct {
+	Stocks     []model.StockDataInfo
+	NextCursor string
+}
+
+type cursorPosition struct {
+	Sort  string      `json:"s"`
+	Value interface{} `json:"v,omitempty"`
+	// the stocks without a sort value come last in both directions
+	Missing bool   `json:"m,omitempty"`
+	Ticker  string `json:"t"`
+}
+
+var derivedExpressions = map[string]bson.D{
+	"currentPe": {{Key: "$cond", Value: bson.A{
+		bson.D{{Key: "$eq", Value: bson.A{"$eps", 0}}},
+		0,
+		bson.D{{Key: "$divide", Value: bson.A{"$price", "$eps"}}}}}},
+	"currentDividendYield": {{Key: "$cond", Value: bson.A{
+		bson.D{{Key: "$eq", Value: bson.A{"$price", 0}}},
+		0,
+		bson.D{{Key: "$multiply", Value: bson.A{
+			bson.D{{Key: "$divide", Value: bson.A{
+				bson.D{{Key: "$multiply", Value: bson.A{"$dividend", 4}}},
+				"$price"}}},
+			100}}}}}},
+}
+
+//SortKey identifies the ordering of the query, descending fields are prefixed with '-'
+func (q Query) SortKey() string {
+	name := q.SortField.Name
+	if name == "" {
+		name = "ticker"
+	}
+
+	if q.Descending {
+		return "-" + name
+	}
+
+	return name
+}
+
+//SetSort orders the query by the field named by the sort key, the reverse of SortKey
+func (q *Query) SetSort(key string) error {
+	name := strings.TrimPrefix(key, "-")
+	field, ok := model.LookupField(name)
+	if !ok {
+		return fmt.Errorf("unknown sort field [%s]", name)
+	}
+	if !field.Numeric() && field.Name != "ticker" {
+		return fmt.Errorf("can't sort by [%s]", name)
+	}
+
+	q.SortField = field
+	q.Descending = strings.HasPrefix(key, "-")
+
+	return nil
+}
+
+func (q Query) sortsByTicker() bool {
+	return q.SortField.Name == "" || q.SortField.Name == "ticker"
+}
+
+func (q Query) pipeline() (bson.A, error) {
+	pipeline := bson.A{}
+
+	direction := 1
+	comparison := "$gt"
+	if q.Descending {
+		direction = -1
+		comparison = "$lt"
+	}
+
+	var position *cursorPosition
+	if q.Cursor != "" {
+		p, err := decodeCursor(q.Cursor)
+		if err != nil || p.Sort != q.SortKey() {
+			return nil, ErrInvalidCursor
+		}
+		position = &p
+	}
+
+	if q.sortsByTicker() {
+		if position != nil {
+			pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.D{
+				{Key: "ticker", Value: bson.D{{Key: comparison, Value: position.Ticker}}}}}})
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{{Key: "ticker", Value: direction}}}})
+	} else {
+		var sortValue interface{} = "$" + q.SortField.Path
+		if q.SortField.Derived() {
+			sortValue = derivedExpressions[q.SortField.Name]
+		}
+		missing := bson.D{{Key: "$cond", Value: bson.A{
+			bson.D{{Key: "$in", Value: bson.A{bson.D{{Key: "$type", Value: sortValue}}, bson.A{"missing", "null"}}}},
+			1,
+			0}}}
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.D{
+			{Key: sortValueKey, Value: sortValue},
+			{Key: sortMissingKey, Value: missing}}}})
+
+		if position != nil {
+			pipeline = append(pipeline, bson.D{{Key: "$match", Value: after(*position, comparison)}})
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{{Key: sortMissingKey, Value: 1}, {Key: sortValueKey, Value: direction}, {Key: "ticker", Value: 1}}}})
+	}
+
+	if q.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: q.Limit + 1}})
+	}
+
+	if len(q.Fields) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: projection(q.Fields)}})
+	}
+
+	return pipeline, nil
+}
+
+// after matches the stocks following the position, the ones without a sort value are ordered by their ticker after the rest
+func after(position cursorPosition, comparison string) bson.D {
+	nextMissing := bson.D{{Key: sortMissingKey, Value: 1}, {Key: "ticker", Value: bson.D{{Key: "$gt", Value: position.Ticker}}}}
+
+	if position.Missing {
+		return nextMissing
+	}
+
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: sortMissingKey, Value: 0}, {Key: sortValueKey, Value: bson.D{{Key: comparison, Value: position.Value}}}},
+		bson.D{{Key: sortMissingKey, Value: 0}, {Key: sortValueKey, Value: position.Value}, {Key: "ticker", Value: bson.D{{Key: "$gt", Value: position.Ticker}}}},
+		bson.D{{Key: sortMissingKey, Value: 1}}}}}
+}
+
+func projection(selected []model.Field) bson.D {
+	paths := []string{"ticker", sortValueKey, sortMissingKey}
+	for _, f := range selected {
+		if f.Derived() {
+			paths = append(paths, f.Requires...)
+		} else {
+			paths = append(paths, f.Path)
+		}
+	}
+
+	var result bson.D
+	seen := map[string]bool{}
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		result = append(result, bson.E{Key: path, Value: 1})
+	}
+
+	return result
+}
+
+//Stream calls fn with every stock matching the query as it is read from the database cursor
+func (si *Stockinfos) Stream(ctx context.Context, q Query, fn func(model.StockDataInfo) error) error {
+	ctx, span := tracing.Start(ctx, "Stockinfos.Stream", attribute.String("sort", q.SortKey()))
+	defer span.End()
+
+	pipeline, err := q.pipeline()
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	cursor, err := si.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var data model.StockDataInfo
+		if err := cursor.Decode(&data); err != nil {
+			return tracing.Error(span, err)
+		}
+
+		if err := fn(data); err != nil {
+			return tracing.Error(span, err)
+		}
+	}
+
+	return tracing.Error(span, cursor.Err())
+}
+
+//Find returns the page of stocks described by the query
+func (si *Stockinfos) Find(ctx context.Context, q Query) (Page, error) {
+	ctx, span := tracing.Start(ctx, "Stockinfos.Find", attribute.String("sort", q.SortKey()), attribute.Int("limit", q.Limit))
+	defer span.End()
+
+	pipeline, err := q.pipeline()
+	if err != nil {
+		return Page{}, tracing.Error(span, err)
+	}
+
+	cursor, err := si.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return Page{}, tracing.Error(span, err)
+	}
+	defer cursor.Close(ctx)
+
+	var page Page
+	var last bson.Raw
+
+	for cursor.Next(ctx) {
+		if q.Limit > 0 && len(page.Stocks) == q.Limit {
+			page.NextCursor = encodeCursor(q, page.Stocks[len(page.Stocks)-1], last)
+			break
+		}
+
+		var data model.StockDataInfo
+		if err := cursor.Decode(&data); err != nil {
+			return Page{}, tracing.Error(span, err)
+		}
+		page.Stocks = append(page.Stocks, data)
+		last = cursor.Current
+	}
+
+	return page, tracing.Error(span, cursor.Err())
+}
+
+func encodeCursor(q Query, stock model.StockDataInfo, raw bson.Raw) string {
+	position := cursorPosition{Sort: q.SortKey(), Ticker: stock.Ticker}
+
+	if !q.sortsByTicker() {
+		position.Value = rawNumber(raw.Lookup(sortValueKey))
+		position.Missing = rawNumber(raw.Lookup(sortMissingKey)) == float64(1)
+	}
+
+	data, _ := json.Marshal(position)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (cursorPosition, error) {
+	var position cursorPosition
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return position, err
+	}
+
+	err = json.Unmarshal(data, &position)
+
+	return position, err
+}
+
+func rawNumber(value bson.RawValue) interface{} {
+	switch value.Type {
+	case bsontype.Double:
+		return value.Double()
+	case bsontype.Int32:
+		return float64(value.Int32())
+	case bsontype.Int64:
+		return float64(value.Int64())
+	default:
+		return nil
+	}
+}
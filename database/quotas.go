@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Quotas struct {
+	collection *mongo.Collection
+}
+
+func NewQuotas(db *mongo.Database) *Quotas {
+	collection := db.Collection("quotas")
+
+	// the counters of past periods are removed by mongo once they expire
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+
+	if err != nil {
+		logrus.Errorf("Failed to create the expiry index of the quotas: %v\n", err)
+	}
+
+	return &Quotas{
+		collection: collection,
+	}
+}
+
+//Take increments the usage of the key if it's below the limit and reports whether it was.
+//Concurrent callers can't exceed the limit, the counter is only matched while it's below.
+func (q *Quotas) Take(ctx context.Context, key string, limit int, expires time.Time) (bool, error) {
+	filter := bson.D{{Key: "_id", Value: key}, {Key: "count", Value: bson.M{"$lt": limit}}}
+	update := bson.M{
+		"$inc":         bson.M{"count": 1},
+		"$setOnInsert": bson.M{"expires": expires},
+	}
+
+	_, err := q.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+
+	// the upsert of an exhausted counter collides with the existing document
+	if isDuplicateKey(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+//Release gives back a unit taken from the key
+func (q *Quotas) Release(ctx context.Context, key string) error {
+	filter := bson.D{{Key: "_id", Value: key}, {Key: "count", Value: bson.M{"$gt": 0}}}
+
+	_, err := q.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"count": -1}})
+
+	return err
+}
+
+// duplicateKeyCode is the server error of a unique index violation
+const duplicateKeyCode = 11000
+
+func isDuplicateKey(err error) bool {
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for _, e := range writeErr.WriteErrors {
+			if e.Code == duplicateKeyCode {
+				return true
+			}
+		}
+	}
+
+	var commandErr mongo.CommandError
+	return errors.As(err, &commandErr) && commandErr.Code == duplicateKeyCode
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+
+	"github.com/nagymarci/stock-screener/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// the current settings are a single document, every change is kept in the history collection
+const settingsID = "runtime"
+
+type Settings struct {
+	collection *mongo.Collection
+	history    *mongo.Collection
+}
+
+func NewSettings(db *mongo.Database) *Settings {
+	return &Settings{
+		collection: db.Collection("settings"),
+		history:    db.Collection("settingsHistory"),
+	}
+}
+
+//Get retreives the persisted settings, mongo.ErrNoDocuments is returned if they were never changed
+func (s *Settings) Get(ctx context.Context) (model.Settings, error) {
+	var result model.Settings
+
+	err := s.collection.FindOne(ctx, bson.D{{Key: "_id", Value: settingsID}}).Decode(&result)
+
+	return result, err
+}
+
+//Save records the change in the history and persists the new settings
+func (s *Settings) Save(ctx context.Context, change model.SettingsChange) error {
+	_, err := s.history.InsertOne(ctx, change)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.collection.ReplaceOne(ctx, bson.D{{Key: "_id", Value: settingsID}}, change.Current, options.Replace().SetUpsert(true))
+
+	return err
+}
+
+//History retreives the latest changes, newest first
+func (s *Settings) History(ctx context.Context, limit int64) ([]model.SettingsChange, error) {
+	cursor, err := s.history.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "changed", Value: -1}}).SetLimit(limit))
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []model.SettingsChange{}
+
+	err = cursor.All(ctx, &result)
+
+	return result, err
+}
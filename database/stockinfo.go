@@ -2,47 +2,230 @@ package database
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/tracing"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Stockinfos struct {
 	collection *mongo.Collection
+	// the audit trail of the written values
+	changes *mongo.Collection
 }
 
 func NewStockinfos(db *mongo.Database) *Stockinfos {
 	return &Stockinfos{
 		collection: db.Collection("stockinfo"),
+		changes:    db.Collection("changes"),
 	}
 }
 
-//Save writes the stockData to the database
-func (si *Stockinfos) Save(stockData model.StockDataInfo) error {
-	_, err := si.collection.InsertOne(context.TODO(), stockData)
+//Save writes the stockData to the database, its values are recorded as changes from zero
+func (si *Stockinfos) Save(ctx context.Context, stockData model.StockDataInfo, cause model.Cause) error {
+	ctx, span := tracing.Start(ctx, "Stockinfos.Save", attribute.String("symbol", stockData.Ticker))
+	defer span.End()
 
-	return err
+	_, err := si.collection.InsertOne(ctx, stockData)
+
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	return tracing.Error(span, si.record(ctx, model.StockDataInfo{}, stockData, cause))
 }
 
-//Update sets the fields that were changed in the DB
-func (si *Stockinfos) Update(stockData model.StockDataInfo) error {
+//Update sets the fields that were changed in the DB, records the changed values and returns the stored stock.
+//The schedule and provenance of the groups of the fetched provider fields are set even when their values are zero.
+func (si *Stockinfos) Update(ctx context.Context, stockData model.StockDataInfo, fetched []string, cause model.Cause) (model.StockDataInfo, error) {
+	ctx, span := tracing.Start(ctx, "Stockinfos.Update", attribute.String("symbol", stockData.Ticker))
+	defer span.End()
+
 	filter := bson.D{{Key: "ticker", Value: stockData.Ticker}}
 
-	update := bson.A{bson.D{{Key: "$set", Value: composeSetFields(&stockData)}}}
+	update := bson.A{bson.D{{Key: "$set", Value: pin(composeSetFields(&stockData, fetched), time.Now())}}}
+
+	var old model.StockDataInfo
+	err := si.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&old)
+
+	if err != nil {
+		return model.StockDataInfo{}, tracing.Error(span, err)
+	}
+
+	result, err := si.written(ctx, filter, old, cause)
+
+	return result, tracing.Error(span, err)
+}
+
+//Upsert sets the given fields of the stock and the provenance and next update of their groups, the stock is created when it doesn't exist.
+//It records the changed values and returns the stored stock.
+func (si *Stockinfos) Upsert(ctx context.Context, stockData model.StockDataInfo, fields []model.Field, source string, cause model.Cause) (model.StockDataInfo, error) {
+	ctx, span := tracing.Start(ctx, "Stockinfos.Upsert", attribute.String("symbol", stockData.Ticker))
+	defer span.End()
+
+	filter := bson.D{{Key: "ticker", Value: stockData.Ticker}}
 
-	_, err := si.collection.UpdateOne(context.TODO(), filter, update)
+	var setFields bson.D
+	groups := map[string]bool{}
+	for _, f := range fields {
+		if !f.Derived() {
+			setFields = append(setFields, bson.E{Key: f.Path, Value: f.Value(stockData)})
+			groups[f.Group()] = true
+		}
+	}
+
+	now := time.Now()
+	for group := range groups {
+		prefix := ""
+		if group != "" {
+			prefix = group + "."
+		}
+		setFields = append(setFields, bson.E{Key: prefix + "lastUpdated", Value: now}, bson.E{Key: prefix + "source", Value: source})
+
+		// the written groups aren't fetched from the provider before their next update
+		if next := nextUpdateOf(stockData, group); !next.IsZero() {
+			setFields = append(setFields, bson.E{Key: prefix + "nextUpdate", Value: next})
+		}
+	}
+
+	var update interface{} = bson.A{bson.D{{Key: "$set", Value: pin(setFields, now)}}}
+	if len(setFields) == 0 {
+		update = bson.D{{Key: "$setOnInsert", Value: bson.D{{Key: "ticker", Value: stockData.Ticker}}}}
+	}
+
+	// the stock didn't exist before when nothing is returned
+	var old model.StockDataInfo
+	err := si.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)).Decode(&old)
+
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return model.StockDataInfo{}, tracing.Error(span, err)
+	}
+
+	result, err := si.written(ctx, filter, old, cause)
+
+	return result, tracing.Error(span, err)
+}
+
+//Postpone sets the next update times of the stock without changing its values, the keys are the paths of the nextUpdate fields
+func (si *Stockinfos) Postpone(ctx context.Context, ticker string, nextUpdates map[string]time.Time) error {
+	ctx, span := tracing.Start(ctx, "Stockinfos.Postpone", attribute.String("symbol", ticker))
+	defer span.End()
+
+	if len(nextUpdates) == 0 {
+		return nil
+	}
+
+	filter := bson.D{{Key: "ticker", Value: ticker}}
+
+	setFields := bson.M{}
+	for path, next := range nextUpdates {
+		setFields[path] = next
+	}
+
+	_, err := si.collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: setFields}})
+
+	return tracing.Error(span, err)
+}
+
+func nextUpdateOf(stockData model.StockDataInfo, group string) time.Time {
+	switch group {
+	case "peRatio5yr":
+		return stockData.PeRatio5yr.NextUpdate
+	case "dividendYield5yr":
+		return stockData.DividendYield5yr.NextUpdate
+	default:
+		return stockData.NextUpdate
+	}
+}
+
+// written reads back the stock after the write and records the values changed since old.
+// In a transaction the read sees the write and nothing after it.
+func (si *Stockinfos) written(ctx context.Context, filter bson.D, old model.StockDataInfo, cause model.Cause) (model.StockDataInfo, error) {
+	var result model.StockDataInfo
+
+	if err := si.collection.FindOne(ctx, filter).Decode(&result); err != nil {
+		return result, err
+	}
+
+	return result, si.record(ctx, old, result, cause)
+}
+
+func (si *Stockinfos) record(ctx context.Context, old, new model.StockDataInfo, cause model.Cause) error {
+	changes := model.Diff(old, new)
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	documents := make([]interface{}, 0, len(changes))
+	for _, change := range changes {
+		documents = append(documents, model.StockChange{Ticker: new.Ticker, FieldChange: change, Time: now, Cause: cause})
+	}
+
+	_, err := si.changes.InsertMany(ctx, documents)
 
 	return err
 }
 
-func composeSetFields(stockData *model.StockDataInfo) bson.D {
+//Changes returns the audit trail of the stock up to the limit, newest first. An empty field returns every field.
+func (si *Stockinfos) Changes(ctx context.Context, symbol model.Symbol, field string, limit int) ([]model.StockChange, error) {
+	ctx, span := tracing.Start(ctx, "Stockinfos.Changes", attribute.String("symbol", symbol.String()))
+	defer span.End()
+
+	filter := bson.D{{Key: "ticker", Value: symbol.String()}}
+	if field != "" {
+		filter = append(filter, bson.E{Key: "field", Value: field})
+	}
+
+	// the changes of one write share the time, their ids keep the display order of the fields
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: -1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit))
+
+	cursor, err := si.changes.Find(ctx, filter, opts)
+
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	result := []model.StockChange{}
+
+	err = cursor.All(ctx, &result)
+
+	return result, tracing.Error(span, err)
+}
+
+// fetchedGroups returns the groups of the provider fields by the path of their schedule
+func fetchedGroups(fetched []string) map[string]bool {
+	result := map[string]bool{}
+
+	for _, field := range fetched {
+		switch field {
+		case "price", "eps", "div":
+			result["nextUpdate"] = true
+		case "divHist":
+			result["dividendYield5yr.nextUpdate"] = true
+		case "pe":
+			result["peRatio5yr.nextUpdate"] = true
+		}
+	}
+
+	return result
+}
+
+func composeSetFields(stockData *model.StockDataInfo, fetched []string) bson.D {
 	var setFields bson.D
+	groups := fetchedGroups(fetched)
 
-	if stockData.Price != 0 || stockData.Eps != 0 || stockData.Dividend != 0 {
+	if groups["nextUpdate"] || stockData.Price != 0 || stockData.Eps != 0 || stockData.Dividend != 0 {
 		setFields = append(setFields, bson.E{Key: "nextUpdate", Value: stockData.NextUpdate})
+		setFields = append(setFields, bson.E{Key: "lastUpdated", Value: stockData.LastUpdated})
+		setFields = append(setFields, bson.E{Key: "source", Value: stockData.Source})
 	}
 
 	if stockData.Price != 0 {
@@ -57,39 +240,204 @@ func composeSetFields(stockData *model.StockDataInfo) bson.D {
 		setFields = append(setFields, bson.E{Key: "dividend", Value: stockData.Dividend})
 	}
 
-	if stockData.DividendYield5yr.Avg != 0 || stockData.DividendYield5yr.Max != 0 {
-		setFields = append(setFields, bson.E{Key: "dividendYield5yr", Value: stockData.DividendYield5yr})
+	// the groups are set field by field, so the pinned values can be kept
+	if h := stockData.DividendYield5yr; h.Avg != 0 || h.Max != 0 {
+		setFields = append(setFields,
+			bson.E{Key: "dividendYield5yr.avg", Value: h.Avg},
+			bson.E{Key: "dividendYield5yr.max", Value: h.Max})
 	}
 
-	if stockData.PeRatio5yr.Avg != 0 || stockData.PeRatio5yr.Min != 0 {
-		setFields = append(setFields, bson.E{Key: "peRatio5yr", Value: stockData.PeRatio5yr})
+	// stocks without dividends have zero history, the schedule of the fetched group moves on anyway
+	if h := stockData.DividendYield5yr; groups["dividendYield5yr.nextUpdate"] || h.Avg != 0 || h.Max != 0 {
+		setFields = append(setFields,
+			bson.E{Key: "dividendYield5yr.nextUpdate", Value: h.NextUpdate},
+			bson.E{Key: "dividendYield5yr.lastUpdated", Value: h.LastUpdated},
+			bson.E{Key: "dividendYield5yr.source", Value: h.Source})
+	}
+
+	if h := stockData.PeRatio5yr; h.Avg != 0 || h.Min != 0 {
+		setFields = append(setFields,
+			bson.E{Key: "peRatio5yr.avg", Value: h.Avg},
+			bson.E{Key: "peRatio5yr.min", Value: h.Min})
+	}
+
+	if h := stockData.PeRatio5yr; groups["peRatio5yr.nextUpdate"] || h.Avg != 0 || h.Min != 0 {
+		setFields = append(setFields,
+			bson.E{Key: "peRatio5yr.nextUpdate", Value: h.NextUpdate},
+			bson.E{Key: "peRatio5yr.lastUpdated", Value: h.LastUpdated},
+			bson.E{Key: "peRatio5yr.source", Value: h.Source})
 	}
 
 	return setFields
 }
 
+// pin turns the set fields into expressions of an update pipeline that keep the stored value of the fields with an active override.
+// It's evaluated by the database, so an override set during an update isn't overwritten.
+func pin(setFields bson.D, now time.Time) bson.D {
+	result := make(bson.D, 0, len(setFields))
+
+	for _, e := range setFields {
+		if f, ok := model.LookupField(e.Key); !ok || f.Set == nil {
+			// strings starting with $ would be read as field paths
+			result = append(result, bson.E{Key: e.Key, Value: bson.D{{Key: "$literal", Value: e.Value}}})
+			continue
+		}
+
+		active := bson.D{{Key: "$filter", Value: bson.D{
+			{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$overrides", bson.A{}}}}},
+			{Key: "cond", Value: bson.D{{Key: "$and", Value: bson.A{
+				bson.D{{Key: "$eq", Value: bson.A{"$$this.field", e.Key}}},
+				bson.D{{Key: "$or", Value: bson.A{
+					bson.D{{Key: "$eq", Value: bson.A{bson.D{{Key: "$type", Value: "$$this.expires"}}, "missing"}}},
+					bson.D{{Key: "$gt", Value: bson.A{"$$this.expires", now}}},
+				}}},
+			}}}},
+		}}}
+
+		value := bson.D{{Key: "$cond", Value: bson.A{
+			bson.D{{Key: "$gt", Value: bson.A{bson.D{{Key: "$size", Value: active}}, 0}}},
+			"$" + e.Key,
+			bson.D{{Key: "$literal", Value: e.Value}},
+		}}}
+
+		result = append(result, bson.E{Key: e.Key, Value: value})
+	}
+
+	return result
+}
+
+//Override replaces the overrides of the stock and sets the pinned values, it records the changed values and returns the stored stock.
+//The pinned values replace the provider ones, so the groups of removed overrides are fetched again right away,
+//and the groups of expiring ones at the expiry.
+func (si *Stockinfos) Override(ctx context.Context, symbol model.Symbol, overrides []model.Override, cause model.Cause) (model.StockDataInfo, error) {
+	ctx, span := tracing.Start(ctx, "Stockinfos.Override", attribute.String("symbol", symbol.String()))
+	defer span.End()
+
+	filter := bson.D{{Key: "ticker", Value: symbol.String()}}
+
+	setFields := bson.D{{Key: "overrides", Value: overrides}}
+	expiries := bson.M{}
+	for _, o := range overrides {
+		setFields = append(setFields, bson.E{Key: o.Field, Value: o.Value})
+
+		if f, ok := model.LookupField(o.Field); ok && !o.Expires.IsZero() {
+			expiries[f.NextUpdatePath()] = earliest(expiries[f.NextUpdatePath()], o.Expires)
+		}
+	}
+
+	update := bson.D{{Key: "$set", Value: setFields}}
+	if len(expiries) > 0 {
+		update = append(update, bson.E{Key: "$min", Value: expiries})
+	}
+
+	var old model.StockDataInfo
+	err := si.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&old)
+
+	if err != nil {
+		return model.StockDataInfo{}, tracing.Error(span, err)
+	}
+
+	if err := si.refetchRemoved(ctx, filter, old.Overrides, overrides); err != nil {
+		return model.StockDataInfo{}, tracing.Error(span, err)
+	}
+
+	result, err := si.written(ctx, filter, old, cause)
+
+	return result, tracing.Error(span, err)
+}
+
+// refetchRemoved brings the next update of the groups pinned by the old overrides but not the new ones forward to now
+func (si *Stockinfos) refetchRemoved(ctx context.Context, filter bson.D, old, overrides []model.Override) error {
+	kept := map[string]bool{}
+	for _, o := range overrides {
+		kept[o.Field] = true
+	}
+
+	now := time.Now()
+	nextUpdates := bson.M{}
+	for _, o := range old {
+		if f, ok := model.LookupField(o.Field); ok && !kept[o.Field] {
+			nextUpdates[f.NextUpdatePath()] = now
+		}
+	}
+
+	if len(nextUpdates) == 0 {
+		return nil
+	}
+
+	_, err := si.collection.UpdateOne(ctx, filter, bson.D{{Key: "$min", Value: nextUpdates}})
+
+	return err
+}
+
+func earliest(current interface{}, t time.Time) time.Time {
+	if c, ok := current.(time.Time); ok && c.Before(t) {
+		return c
+	}
+
+	return t
+}
+
 //Get retreives the stockinfo for the given symbol
-func (si *Stockinfos) Get(symbol string) (model.StockDataInfo, error) {
+func (si *Stockinfos) Get(ctx context.Context, symbol model.Symbol) (model.StockDataInfo, error) {
+	ctx, span := tracing.Start(ctx, "Stockinfos.Get", attribute.String("symbol", symbol.String()))
+	defer span.End()
+
 	var result model.StockDataInfo
 
-	filter := bson.D{primitive.E{Key: "ticker", Value: symbol}}
+	filter := bson.D{primitive.E{Key: "ticker", Value: symbol.String()}}
 
-	err := si.collection.FindOne(context.TODO(), filter).Decode(&result)
+	err := si.collection.FindOne(ctx, filter).Decode(&result)
 
-	return result, err
+	// the lookup of a new stock is expected to find nothing, it isn't a failure of the span
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return result, err
+	}
+
+	return result, tracing.Error(span, err)
+}
+
+//GetMany retreives the stockinfos of the symbols with one query, unknown symbols are left out
+func (si *Stockinfos) GetMany(ctx context.Context, symbols []model.Symbol) ([]model.StockDataInfo, error) {
+	ctx, span := tracing.Start(ctx, "Stockinfos.GetMany", attribute.Int("symbols", len(symbols)))
+	defer span.End()
+
+	result := []model.StockDataInfo{}
+
+	cursor, err := si.collection.Find(ctx, bson.D{{Key: "ticker", Value: bson.D{{Key: "$in", Value: tickers(symbols)}}}})
+
+	if err != nil {
+		return result, tracing.Error(span, err)
+	}
+
+	err = cursor.All(ctx, &result)
+
+	return result, tracing.Error(span, err)
+}
+
+func tickers(symbols []model.Symbol) []string {
+	result := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		result = append(result, symbol.String())
+	}
+
+	return result
 }
 
 //GetAll retreives all of the objects from the database
-func (si *Stockinfos) GetAll() ([]model.StockDataInfo, error) {
-	cursor, err := si.collection.Find(context.TODO(), bson.M{})
+func (si *Stockinfos) GetAll(ctx context.Context) ([]model.StockDataInfo, error) {
+	ctx, span := tracing.Start(ctx, "Stockinfos.GetAll")
+	defer span.End()
+
+	cursor, err := si.collection.Find(ctx, bson.M{})
 
 	if err != nil {
-		return nil, err
+		return nil, tracing.Error(span, err)
 	}
 
 	var result []model.StockDataInfo
 
-	for cursor.Next(context.TODO()) {
+	for cursor.Next(ctx) {
 		var data model.StockDataInfo
 		cursor.Decode(&data)
 		result = append(result, data)
@@ -99,24 +447,29 @@ func (si *Stockinfos) GetAll() ([]model.StockDataInfo, error) {
 }
 
 //GetAllExpired returns list of stocks that has at least one value expired
-func (si *Stockinfos) GetAllExpired() ([]model.StockDataInfo, error) {
+func (si *Stockinfos) GetAllExpired(ctx context.Context) ([]model.StockDataInfo, error) {
+	ctx, span := tracing.Start(ctx, "Stockinfos.GetAllExpired")
+	defer span.End()
+
 	now := time.Now()
 
 	filter := bson.D{{Key: "$or", Value: bson.A{
 		bson.D{{Key: "nextUpdate", Value: bson.D{{Key: "$lt", Value: now}}}},
 		bson.D{{Key: "dividendYield5yr.nextUpdate", Value: bson.D{{Key: "$lt", Value: now}}}},
 		bson.D{{Key: "peRatio5yr.nextUpdate", Value: bson.D{{Key: "$lt", Value: now}}}},
-		bson.D{{Key: "nextUpdate", Value: nil}}}}}
+		bson.D{{Key: "nextUpdate", Value: nil}},
+		bson.D{{Key: "dividendYield5yr.nextUpdate", Value: nil}},
+		bson.D{{Key: "peRatio5yr.nextUpdate", Value: nil}}}}}
 
-	cursor, err := si.collection.Find(context.TODO(), filter)
+	cursor, err := si.collection.Find(ctx, filter)
 
 	if err != nil {
-		return nil, err
+		return nil, tracing.Error(span, err)
 	}
 
 	var result []model.StockDataInfo
 
-	for cursor.Next(context.TODO()) {
+	for cursor.Next(ctx) {
 		var data model.StockDataInfo
 		cursor.Decode(&data)
 		result = append(result, data)
@@ -125,11 +478,71 @@ func (si *Stockinfos) GetAllExpired() ([]model.StockDataInfo, error) {
 	return result, err
 }
 
-//Delete removes the given symbol from the database
-func (si *Stockinfos) Delete(symbol string) error {
-	filter := bson.D{{Key: "ticker", Value: symbol}}
+//OldestUpdates returns the earliest update time of each field group, groups without update time are left out
+func (si *Stockinfos) OldestUpdates(ctx context.Context) (map[string]time.Time, error) {
+	ctx, span := tracing.Start(ctx, "Stockinfos.OldestUpdates")
+	defer span.End()
 
-	_, err := si.collection.DeleteOne(context.TODO(), filter)
+	pipeline := bson.A{bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: nil},
+		{Key: "price", Value: bson.D{{Key: "$min", Value: "$lastUpdated"}}},
+		{Key: "peRatio5yr", Value: bson.D{{Key: "$min", Value: "$peRatio5yr.lastUpdated"}}},
+		{Key: "dividendYield5yr", Value: bson.D{{Key: "$min", Value: "$dividendYield5yr.lastUpdated"}}}}}}}
 
-	return err
+	cursor, err := si.collection.Aggregate(ctx, pipeline)
+
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+	defer cursor.Close(ctx)
+
+	result := map[string]time.Time{}
+
+	if !cursor.Next(ctx) {
+		return result, tracing.Error(span, cursor.Err())
+	}
+
+	var oldest struct {
+		Price            *time.Time `bson:"price"`
+		PeRatio5yr       *time.Time `bson:"peRatio5yr"`
+		DividendYield5yr *time.Time `bson:"dividendYield5yr"`
+	}
+
+	if err := cursor.Decode(&oldest); err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	for group, updated := range map[string]*time.Time{"price": oldest.Price, "peRatio5yr": oldest.PeRatio5yr, "dividendYield5yr": oldest.DividendYield5yr} {
+		if updated != nil && !updated.IsZero() {
+			result[group] = *updated
+		}
+	}
+
+	return result, nil
+}
+
+//Delete removes the given symbol from the database, mongo.ErrNoDocuments is returned for unknown symbols
+func (si *Stockinfos) Delete(ctx context.Context, symbol model.Symbol) error {
+	ctx, span := tracing.Start(ctx, "Stockinfos.Delete", attribute.String("symbol", symbol.String()))
+	defer span.End()
+
+	filter := bson.D{{Key: "ticker", Value: symbol.String()}}
+
+	result, err := si.collection.DeleteOne(ctx, filter)
+
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+//MigrateSymbols rewrites the tickers stored before symbols were normalised to the canonical form,
+//of duplicate stocks the most recently updated is kept. It returns the number of migrated and dropped stocks.
+func (si *Stockinfos) MigrateSymbols(ctx context.Context) (int, int, error) {
+	return migrateSymbols(ctx, si.collection, "lastUpdated")
 }
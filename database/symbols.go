@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nagymarci/stock-screener/model"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrateSymbols sets the canonical ticker on every document of the collection, the ones that can't be parsed are left for manual cleanup.
+// When the canonical ticker is stored already, the document with the newer time at the updated path is kept and the other one is dropped.
+// It returns the number of migrated documents and of dropped duplicates.
+func migrateSymbols(ctx context.Context, collection *mongo.Collection, updated string) (int, int, error) {
+	log := log.WithField("collection", collection.Name())
+
+	projection := bson.D{{Key: "ticker", Value: 1}, {Key: updated, Value: 1}}
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	migrated, duplicates := 0, 0
+
+	for cursor.Next(ctx) {
+		doc := cursor.Current
+		id := doc.Lookup("_id")
+		ticker, _ := doc.Lookup("ticker").StringValueOK()
+
+		symbol, err := model.ParseSymbol(ticker)
+
+		if err != nil {
+			log.Warnln(err)
+			continue
+		}
+
+		canonical := symbol.String()
+		if canonical == ticker {
+			continue
+		}
+
+		var existing bson.Raw
+		err = collection.FindOne(ctx, bson.D{{Key: "ticker", Value: canonical}}, options.FindOne().SetProjection(projection)).Decode(&existing)
+
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+		case err != nil:
+			return migrated, duplicates, err
+		case updatedAt(doc, updated).After(updatedAt(existing, updated)):
+			// the legacy document is newer, it replaces the canonical one
+			log.WithField("id", existing.Lookup("_id")).WithField("ticker", canonical).Warnf("Dropped older duplicate of [%s]\n", ticker)
+			if _, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: existing.Lookup("_id")}}); err != nil {
+				return migrated, duplicates, err
+			}
+			duplicates++
+		default:
+			log.WithField("id", id).WithField("ticker", ticker).Warnf("Dropped duplicate of [%s]\n", canonical)
+			if _, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}}); err != nil {
+				return migrated, duplicates, err
+			}
+			duplicates++
+			continue
+		}
+
+		_, err = collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{{Key: "ticker", Value: canonical}}}})
+
+		if err != nil {
+			return migrated, duplicates, err
+		}
+
+		migrated++
+	}
+
+	return migrated, duplicates, cursor.Err()
+}
+
+// updatedAt reads the time at the dot separated path, documents without one are the oldest
+func updatedAt(doc bson.Raw, path string) time.Time {
+	if t, ok := doc.Lookup(strings.Split(path, ".")...).TimeOK(); ok {
+		return t
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/nagymarci/stock-screener/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Webhooks struct {
+	collection *mongo.Collection
+}
+
+func NewWebhooks(db *mongo.Database) *Webhooks {
+	return &Webhooks{
+		collection: db.Collection("webhooks"),
+	}
+}
+
+//Save stores the new webhook
+func (wh *Webhooks) Save(ctx context.Context, webhook model.Webhook) error {
+	_, err := wh.collection.InsertOne(ctx, webhook)
+
+	return err
+}
+
+//Get retreives the webhook by id
+func (wh *Webhooks) Get(ctx context.Context, id string) (model.Webhook, error) {
+	var result model.Webhook
+
+	err := wh.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&result)
+
+	return result, err
+}
+
+//GetAll retreives every webhook, oldest first
+func (wh *Webhooks) GetAll(ctx context.Context) ([]model.Webhook, error) {
+	cursor, err := wh.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created", Value: 1}}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []model.Webhook{}
+
+	err = cursor.All(ctx, &result)
+
+	return result, err
+}
+
+//Delete removes the webhook, mongo.ErrNoDocuments is returned for unknown ids
+func (wh *Webhooks) Delete(ctx context.Context, id string) error {
+	result, err := wh.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+type Deliveries struct {
+	collection *mongo.Collection
+}
+
+func NewDeliveries(db *mongo.Database) *Deliveries {
+	return &Deliveries{
+		collection: db.Collection("deliveries"),
+	}
+}
+
+//Enqueue stores the delivery, enqueueing the same delivery again is a no-op
+func (d *Deliveries) Enqueue(ctx context.Context, delivery model.Delivery) error {
+	_, err := d.collection.InsertOne(ctx, delivery)
+
+	if isDuplicateKey(err) {
+		return nil
+	}
+
+	return err
+}
+
+//Claim returns a pending delivery due at now and postpones it by the lease, so concurrent dispatchers don't send it twice.
+//mongo.ErrNoDocuments is returned when nothing is due.
+func (d *Deliveries) Claim(ctx context.Context, now time.Time, lease time.Duration) (model.Delivery, error) {
+	filter := bson.D{{Key: "status", Value: model.DeliveryPending}, {Key: "nextAttempt", Value: bson.M{"$lte": now}}}
+	update := bson.M{
+		"$set": bson.M{"nextAttempt": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "nextAttempt", Value: 1}}).SetReturnDocument(options.After)
+
+	var result model.Delivery
+	err := d.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+
+	return result, err
+}
+
+//Remove deletes the delivery once it's delivered or its webhook is gone
+func (d *Deliveries) Remove(ctx context.Context, id string) error {
+	_, err := d.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+
+	return err
+}
+
+//Retry records the failed attempt and schedules the next one
+func (d *Deliveries) Retry(ctx context.Context, id string, lastError string, next time.Time) error {
+	_, err := d.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.M{"$set": bson.M{"lastError": lastError, "nextAttempt": next}})
+
+	return err
+}
+
+//Kill records the failed attempt and moves the delivery to the dead letters
+func (d *Deliveries) Kill(ctx context.Context, id string, lastError string) error {
+	_, err := d.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.M{"$set": bson.M{"lastError": lastError, "status": model.DeliveryDead}})
+
+	return err
+}
+
+//GetDead retreives the dead letters, newest first
+func (d *Deliveries) GetDead(ctx context.Context) ([]model.Delivery, error) {
+	cursor, err := d.collection.Find(ctx, bson.D{{Key: "status", Value: model.DeliveryDead}}, options.Find().SetSort(bson.D{{Key: "created", Value: -1}}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []model.Delivery{}
+
+	err = cursor.All(ctx, &result)
+
+	return result, err
+}
+
+//Revive makes the dead letter pending again with fresh attempts, mongo.ErrNoDocuments is returned for unknown or pending ids
+func (d *Deliveries) Revive(ctx context.Context, id string, now time.Time) error {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "status", Value: model.DeliveryDead}}
+	update := bson.M{"$set": bson.M{"status": model.DeliveryPending, "attempts": 0, "nextAttempt": now}}
+
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nagymarci/stock-screener/database"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// events and deliveries handled in one round, the rest waits for the next poll
+const batchSize = 100
+
+// the first retry waits minBackoff, the wait doubles with every failed attempt up to maxBackoff
+const (
+	minBackoff = 10 * time.Second
+	maxBackoff = time.Hour
+)
+
+// the answer of the webhook is only read to reuse the connection
+const maxResponseSize = 64 << 10
+
+//Options configures the delivery of the events to the webhooks
+type Options struct {
+	PollInterval time.Duration
+	Timeout      time.Duration
+	MaxAttempts  int
+}
+
+//Dispatcher moves the events of the outbox to the deliveries of the subscribed webhooks and sends them.
+//Every event is delivered at least once, the receivers can drop repeated ones by the delivery header.
+type Dispatcher struct {
+	outbox     *database.Outbox
+	webhooks   *database.Webhooks
+	deliveries *database.Deliveries
+	client     *http.Client
+	options    Options
+}
+
+func NewDispatcher(outbox *database.Outbox, webhooks *database.Webhooks, deliveries *database.Deliveries, options Options) *Dispatcher {
+	return &Dispatcher{
+		outbox:     outbox,
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		client:     &http.Client{Timeout: options.Timeout},
+		options:    options,
+	}
+}
+
+//Run dispatches the events every PollInterval until the context is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.Dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//Dispatch fans the pending events out to the webhooks, then sends the deliveries that are due
+func (d *Dispatcher) Dispatch(ctx context.Context) {
+	log := logrus.WithField("component", "dispatcher")
+
+	if err := d.fanOut(ctx); err != nil {
+		log.Errorln(err)
+	}
+
+	if err := d.deliver(ctx); err != nil {
+		log.Errorln(err)
+	}
+}
+
+func (d *Dispatcher) fanOut(ctx context.Context) error {
+	events, err := d.outbox.Pending(ctx, batchSize)
+
+	if err != nil || len(events) == 0 {
+		return err
+	}
+
+	webhooks, err := d.webhooks.GetAll(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, event := range events {
+		for _, webhook := range webhooks {
+			if !webhook.Accepts(event.Type) {
+				continue
+			}
+
+			delivery := model.Delivery{
+				ID:          event.ID + "." + webhook.ID,
+				Webhook:     webhook.ID,
+				Event:       event,
+				Status:      model.DeliveryPending,
+				NextAttempt: now,
+				Created:     now,
+			}
+
+			// the id is derived from the event, so a repeated fan out after a failure doesn't duplicate it
+			if err := d.deliveries.Enqueue(ctx, delivery); err != nil {
+				return err
+			}
+		}
+
+		if err := d.outbox.Remove(ctx, event.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context) error {
+	for i := 0; i < batchSize; i++ {
+		// a claimed delivery isn't retried before the request times out
+		delivery, err := d.deliveries.Claim(ctx, time.Now(), 2*d.options.Timeout)
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := d.attempt(ctx, delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attempt sends the claimed delivery and records the result, the returned error is of the database
+func (d *Dispatcher) attempt(ctx context.Context, delivery model.Delivery) error {
+	log := logrus.WithField("component", "dispatcher").WithField("delivery", delivery.ID).WithField("attempt", delivery.Attempts)
+
+	webhook, err := d.webhooks.Get(ctx, delivery.Webhook)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		log.Infoln("Dropped the delivery of a deleted webhook")
+		return d.deliveries.Remove(ctx, delivery.ID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = d.send(ctx, webhook, delivery)
+
+	if err == nil {
+		return d.deliveries.Remove(ctx, delivery.ID)
+	}
+
+	if delivery.Attempts >= d.options.MaxAttempts {
+		log.Errorf("Giving up the delivery: %v\n", err)
+		return d.deliveries.Kill(ctx, delivery.ID, err.Error())
+	}
+
+	log.Warnf("Delivery failed, retrying: %v\n", err)
+	return d.deliveries.Retry(ctx, delivery.ID, err.Error(), time.Now().Add(backoff(delivery.Attempts)))
+}
+
+// send posts the signed event to the webhook, any answer but 2xx is a failure
+func (d *Dispatcher) send(ctx context.Context, webhook model.Webhook, delivery model.Delivery) error {
+	body, err := json.Marshal(delivery.Event)
+
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, string(delivery.Event.Type))
+	request.Header.Set(DeliveryHeader, delivery.ID)
+	request.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+
+	response, err := d.client.Do(request)
+
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(response.Body, maxResponseSize))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook answered [%d]", response.StatusCode)
+	}
+
+	return nil
+}
+
+// backoff returns the wait after the failed attempt
+func backoff(attempt int) time.Duration {
+	wait := minBackoff
+	for i := 1; i < attempt && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > maxBackoff {
+		return maxBackoff
+	}
+
+	return wait
+}
@@ -0,0 +1,113 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/nagymarci/stock-screener/database"
+	"github.com/nagymarci/stock-screener/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//Publisher stores the events in the outbox with the changes they report, the dispatcher sends them to the webhooks
+type Publisher struct {
+	outbox *database.Outbox
+}
+
+func NewPublisher(outbox *database.Outbox) *Publisher {
+	return &Publisher{outbox: outbox}
+}
+
+//Record runs the write and stores the events it returns in the same transaction, a nil publisher only runs the write
+func (p *Publisher) Record(ctx context.Context, write func(ctx context.Context) ([]model.Event, error)) error {
+	if p == nil {
+		_, err := write(ctx)
+		return err
+	}
+
+	return p.outbox.Record(ctx, func(ctx context.Context) ([]model.Event, error) {
+		events, err := write(ctx)
+
+		now := time.Now()
+		for i := range events {
+			// object ids grow with the time, so the events are dispatched in order
+			events[i].ID = primitive.NewObjectID().Hex()
+			events[i].Time = now
+		}
+
+		return events, err
+	})
+}
+
+//Publish stores the events of something that didn't write the stocks, e.g. a failed update
+func (p *Publisher) Publish(ctx context.Context, events ...model.Event) error {
+	return p.Record(ctx, func(context.Context) ([]model.Event, error) {
+		return events, nil
+	})
+}
+
+//Registered is the event of the stock added to the watchlist
+func Registered(stock model.StockDataInfo) model.Event {
+	return model.Event{Type: model.EventStockRegistered, Ticker: stock.Ticker, Stock: &stock}
+}
+
+//Deleted is the event of the stock removed from the watchlist
+func Deleted(ticker string) model.Event {
+	return model.Event{Type: model.EventStockDeleted, Ticker: ticker}
+}
+
+//Failed is the event of an update that couldn't fetch the stock
+func Failed(ticker string, err error) model.Event {
+	return model.Event{Type: model.EventUpdateFailed, Ticker: ticker, Error: err.Error()}
+}
+
+// fieldEvents maps the fields fetched from the provider to the event of their group
+var fieldEvents = map[string]model.EventType{
+	"price":   model.EventPriceUpdated,
+	"eps":     model.EventPriceUpdated,
+	"div":     model.EventPriceUpdated,
+	"pe":      model.EventPeHistoryUpdated,
+	"divHist": model.EventDividendHistoryUpdated,
+}
+
+// groupEvents maps the field groups of the import to their event
+var groupEvents = map[string]model.EventType{
+	"":                 model.EventPriceUpdated,
+	"peRatio5yr":       model.EventPeHistoryUpdated,
+	"dividendYield5yr": model.EventDividendHistoryUpdated,
+}
+
+//Updated returns one event per group of the fields fetched from the provider
+func Updated(stock model.StockDataInfo, fields []string) []model.Event {
+	types := map[model.EventType]bool{}
+	for _, field := range fields {
+		types[fieldEvents[field]] = true
+	}
+
+	return updated(stock, types)
+}
+
+//Written returns one event per group of the written values, the ticker alone updates nothing
+func Written(stock model.StockDataInfo, fields []model.Field) []model.Event {
+	types := map[model.EventType]bool{}
+	for _, field := range fields {
+		if field.Set != nil {
+			types[groupEvents[field.Group()]] = true
+		}
+	}
+
+	return updated(stock, types)
+}
+
+func updated(stock model.StockDataInfo, types map[model.EventType]bool) []model.Event {
+	result := []model.Event{}
+
+	for _, t := range model.EventTypes {
+		if types[t] {
+			stock := stock
+			result = append(result, model.Event{Type: t, Ticker: stock.Ticker, Stock: &stock})
+		}
+	}
+
+	return result
+}
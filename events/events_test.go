@@ -0,0 +1,130 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nagymarci/stock-screener/model"
+)
+
+func TestEvents(t *testing.T) {
+	t.Run("one event per updated field group", func(t *testing.T) {
+		result := Updated(model.StockDataInfo{Ticker: "INTC"}, []string{"price", "eps", "div", "pe"})
+
+		if len(result) != 2 || result[0].Type != model.EventPriceUpdated || result[1].Type != model.EventPeHistoryUpdated {
+			t.Fatalf("unexpected events %+v", result)
+		}
+	})
+
+	t.Run("written ticker alone updates nothing", func(t *testing.T) {
+		ticker, _ := model.LookupField("ticker")
+		avg, _ := model.LookupField("dividendYield5yr.avg")
+
+		if result := Written(model.StockDataInfo{Ticker: "INTC"}, []model.Field{ticker}); len(result) != 0 {
+			t.Fatalf("unexpected events %+v", result)
+		}
+
+		if result := Written(model.StockDataInfo{Ticker: "INTC"}, []model.Field{ticker, avg}); len(result) != 1 || result[0].Type != model.EventDividendHistoryUpdated {
+			t.Fatalf("unexpected events %+v", result)
+		}
+	})
+
+	t.Run("nil publisher runs the write", func(t *testing.T) {
+		var publisher *Publisher
+		written := false
+
+		err := publisher.Record(context.Background(), func(context.Context) ([]model.Event, error) {
+			written = true
+			return []model.Event{Deleted("INTC")}, nil
+		})
+
+		if err != nil || !written {
+			t.Fatalf("write is not run, err [%v]", err)
+		}
+	})
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"type":"StockDeleted"}`)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	t.Run("verifies own signature", func(t *testing.T) {
+		if err := Verify("secret", timestamp, Sign("secret", now.Unix(), body), body, now, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("rejects other secret", func(t *testing.T) {
+		err := Verify("other", timestamp, Sign("secret", now.Unix(), body), body, now, time.Minute)
+
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("expected invalid signature, got [%v]", err)
+		}
+	})
+
+	t.Run("rejects old timestamp", func(t *testing.T) {
+		sent := now.Add(-time.Hour)
+		err := Verify("secret", strconv.FormatInt(sent.Unix(), 10), Sign("secret", sent.Unix(), body), body, now, time.Minute)
+
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("expected invalid signature, got [%v]", err)
+		}
+	})
+}
+
+func TestDispatcher(t *testing.T) {
+	t.Run("sends signed event", func(t *testing.T) {
+		var received model.Event
+		var verifyErr error
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			verifyErr = Verify("secret", r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body, time.Now(), time.Minute)
+			json.Unmarshal(body, &received)
+
+			if r.Header.Get(DeliveryHeader) != "1.hook" || r.Header.Get(EventHeader) != string(model.EventStockDeleted) {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		}))
+		defer server.Close()
+
+		dispatcher := NewDispatcher(nil, nil, nil, Options{PollInterval: time.Second, Timeout: time.Second, MaxAttempts: 1})
+		webhook := model.Webhook{ID: "hook", URL: server.URL, Secret: "secret"}
+		delivery := model.Delivery{ID: "1.hook", Event: model.Event{ID: "1", Type: model.EventStockDeleted, Ticker: "INTC"}}
+
+		if err := dispatcher.send(context.Background(), webhook, delivery); err != nil {
+			t.Fatal(err)
+		}
+
+		if verifyErr != nil || received.Ticker != "INTC" {
+			t.Fatalf("unexpected event [%+v], verification [%v]", received, verifyErr)
+		}
+	})
+
+	t.Run("fails on error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		dispatcher := NewDispatcher(nil, nil, nil, Options{PollInterval: time.Second, Timeout: time.Second, MaxAttempts: 1})
+
+		if err := dispatcher.send(context.Background(), model.Webhook{URL: server.URL}, model.Delivery{}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("backoff doubles up to the maximum", func(t *testing.T) {
+		if backoff(1) != minBackoff || backoff(2) != 2*minBackoff || backoff(30) != maxBackoff {
+			t.Fatalf("unexpected backoff [%v] [%v] [%v]", backoff(1), backoff(2), backoff(30))
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Headers of the webhook requests
+const (
+	EventHeader     = "X-Stock-Screener-Event"
+	DeliveryHeader  = "X-Stock-Screener-Delivery"
+	TimestampHeader = "X-Stock-Screener-Timestamp"
+	SignatureHeader = "X-Stock-Screener-Signature"
+)
+
+const signaturePrefix = "sha256="
+
+//ErrInvalidSignature is returned by Verify for requests not signed with the secret, or signed too long ago
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+//Sign returns the signature header of the body sent at the unix timestamp.
+//It's the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret of the webhook.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+//Verify checks the signature and timestamp headers of a received webhook, the timestamp must be within the tolerance of now,
+//so recorded requests can't be replayed later
+func Verify(secret, timestamp, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(sent, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) || !hmac.Equal([]byte(signature), []byte(Sign(secret, sent, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
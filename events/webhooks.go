@@ -0,0 +1,121 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/nagymarci/stock-screener/database"
+	"github.com/nagymarci/stock-screener/model"
+)
+
+//ErrInvalidWebhook is returned for webhook requests with an invalid url or unknown event types
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+//WebhookRequest describes the webhook to create, no types subscribes to every type
+type WebhookRequest struct {
+	URL   string
+	Types []model.EventType
+}
+
+//CreatedWebhook is the stored webhook with the signing secret, the secret is only shown once
+type CreatedWebhook struct {
+	Webhook model.Webhook `json:"webhook"`
+	Secret  string        `json:"secret"`
+}
+
+//Webhooks manages the subscriptions and their dead letters
+type Webhooks struct {
+	store      *database.Webhooks
+	deliveries *database.Deliveries
+}
+
+func NewWebhooks(store *database.Webhooks, deliveries *database.Deliveries) *Webhooks {
+	return &Webhooks{store: store, deliveries: deliveries}
+}
+
+//Create subscribes the url to the events
+func (w *Webhooks) Create(ctx context.Context, request WebhookRequest, createdBy string) (CreatedWebhook, error) {
+	if err := validateWebhookRequest(request); err != nil {
+		return CreatedWebhook{}, err
+	}
+
+	id, secret, err := generateWebhook()
+	if err != nil {
+		return CreatedWebhook{}, err
+	}
+
+	types := request.Types
+	if types == nil {
+		types = []model.EventType{}
+	}
+
+	webhook := model.Webhook{
+		ID:        id,
+		URL:       request.URL,
+		Types:     types,
+		Secret:    secret,
+		Created:   time.Now(),
+		CreatedBy: createdBy,
+	}
+
+	if err := w.store.Save(ctx, webhook); err != nil {
+		return CreatedWebhook{}, err
+	}
+
+	return CreatedWebhook{Webhook: webhook, Secret: secret}, nil
+}
+
+//List returns every webhook without the secrets
+func (w *Webhooks) List(ctx context.Context) ([]model.Webhook, error) {
+	return w.store.GetAll(ctx)
+}
+
+//Delete unsubscribes the webhook, its pending deliveries are dropped
+func (w *Webhooks) Delete(ctx context.Context, id string) error {
+	return w.store.Delete(ctx, id)
+}
+
+//DeadLetters returns the deliveries given up after the last attempt
+func (w *Webhooks) DeadLetters(ctx context.Context) ([]model.Delivery, error) {
+	return w.deliveries.GetDead(ctx)
+}
+
+//Redeliver schedules the dead letter again with every attempt
+func (w *Webhooks) Redeliver(ctx context.Context, id string) error {
+	return w.deliveries.Revive(ctx, id, time.Now())
+}
+
+func validateWebhookRequest(request WebhookRequest) error {
+	u, err := url.Parse(request.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: invalid http(s) url [%s]", ErrInvalidWebhook, request.URL)
+	}
+
+	for _, t := range request.Types {
+		if !t.Valid() {
+			return fmt.Errorf("%w: unknown event type [%s], use one of %v", ErrInvalidWebhook, t, model.EventTypes)
+		}
+	}
+
+	return nil
+}
+
+func generateWebhook() (id string, secret string, err error) {
+	idBytes := make([]byte, 8)
+	secretBytes := make([]byte, 32)
+
+	if _, err = rand.Read(idBytes); err != nil {
+		return "", "", err
+	}
+	if _, err = rand.Read(secretBytes); err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(idBytes), base64.RawURLEncoding.EncodeToString(secretBytes), nil
+}
@@ -0,0 +1,128 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/nagymarci/stock-screener/model"
+)
+
+//Supported export formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+//Writer serializes stocks one by one to the underlying output
+type Writer interface {
+	Write(stock model.StockDataInfo) error
+	Close() error
+}
+
+//New creates a writer for the format writing the selected fields
+func New(format string, w io.Writer, fields []model.Field) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, fields)
+	case FormatNDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w), fields: fields}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, fields)
+	default:
+		return nil, fmt.Errorf("unsupported format [%s]", format)
+	}
+}
+
+//Supported tells if the format can be exported
+func Supported(format string) bool {
+	return format == FormatCSV || format == FormatNDJSON || format == FormatXLSX
+}
+
+//ContentType returns the media type of the format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	fields []model.Field
+}
+
+func newCSVWriter(w io.Writer, fields []model.Field) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.Name
+	}
+
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &csvWriter{writer: writer, fields: fields}, nil
+}
+
+func (cw *csvWriter) Write(stock model.StockDataInfo) error {
+	record := make([]string, len(cw.fields))
+	for i, f := range cw.fields {
+		record[i] = formatValue(f.Value(stock))
+	}
+
+	if err := cw.writer.Write(record); err != nil {
+		return err
+	}
+
+	cw.writer.Flush()
+
+	return cw.writer.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	cw.writer.Flush()
+
+	return cw.writer.Error()
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+	fields  []model.Field
+}
+
+func (nw *ndjsonWriter) Write(stock model.StockDataInfo) error {
+	return nw.encoder.Encode(model.Project(stock, nw.fields))
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
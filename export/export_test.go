@@ -0,0 +1,101 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/nagymarci/stock-screener/model"
+)
+
+func testStock() model.StockDataInfo {
+	stock := model.StockDataInfo{}
+	stock.Ticker = "INTC"
+	stock.Price = 49.28
+	stock.Eps = 5.43
+	stock.Dividend = 0.33
+
+	return stock
+}
+
+func testFields(t *testing.T) []model.Field {
+	fields, unknown := model.ExpandFields([]string{"ticker", "price", "currentPe"})
+	if len(unknown) > 0 {
+		t.Fatalf("unknown fields %v", unknown)
+	}
+
+	return fields
+}
+
+func writeAll(t *testing.T, format string) string {
+	var buf bytes.Buffer
+
+	writer, err := New(format, &buf, testFields(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Write(testStock()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestExport(t *testing.T) {
+	t.Run("writes csv with header", func(t *testing.T) {
+		result := writeAll(t, FormatCSV)
+
+		expected := "ticker,price,currentPe\nINTC,49.28," + formatValue(testStock().CurrentPe()) + "\n"
+		if result != expected {
+			t.Fatalf("unexpected csv [%s]", result)
+		}
+	})
+	t.Run("writes one json object per line", func(t *testing.T) {
+		result := writeAll(t, FormatNDJSON)
+
+		if !strings.HasPrefix(result, `{"currentPe":`) || strings.Count(result, "\n") != 1 {
+			t.Fatalf("unexpected ndjson [%s]", result)
+		}
+	})
+	t.Run("writes workbook with the rows in the sheet", func(t *testing.T) {
+		result := writeAll(t, FormatXLSX)
+
+		archive, err := zip.NewReader(strings.NewReader(result), int64(len(result)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, f := range archive.File {
+			if f.Name != "xl/worksheets/sheet1.xml" {
+				continue
+			}
+
+			r, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := ioutil.ReadAll(r)
+
+			if strings.Count(string(content), "<row>") != 2 || !strings.Contains(string(content), "<t>INTC</t>") {
+				t.Fatalf("unexpected sheet [%s]", content)
+			}
+			return
+		}
+
+		t.Fatalf("sheet is missing")
+	})
+	t.Run("rejects unknown format", func(t *testing.T) {
+		_, err := New("pdf", &bytes.Buffer{}, nil)
+
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/nagymarci/stock-screener/model"
+)
+
+const (
+	xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+	contentTypes = xmlHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	rootRelationships = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	workbook = xmlHeader + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="stocks" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+
+	workbookRelationships = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	sheetStart = xmlHeader + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetEnd   = `</sheetData></worksheet>`
+)
+
+//xlsxWriter writes a single sheet workbook, the rows are streamed into the zip entry of the sheet
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+	fields  []model.Field
+}
+
+func newXLSXWriter(w io.Writer, fields []model.Field) (*xlsxWriter, error) {
+	archive := zip.NewWriter(w)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRelationships},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", workbookRelationships},
+	}
+
+	for _, part := range parts {
+		pw, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := io.WriteString(pw, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.WriteString(sheet, sheetStart); err != nil {
+		return nil, err
+	}
+
+	xw := &xlsxWriter{archive: archive, sheet: sheet, fields: fields}
+
+	header := make([]interface{}, len(fields))
+	for i, f := range fields {
+		header[i] = f.Name
+	}
+
+	return xw, xw.writeRow(header)
+}
+
+func (xw *xlsxWriter) Write(stock model.StockDataInfo) error {
+	values := make([]interface{}, len(xw.fields))
+	for i, f := range xw.fields {
+		values[i] = f.Value(stock)
+	}
+
+	return xw.writeRow(values)
+}
+
+func (xw *xlsxWriter) writeRow(values []interface{}) error {
+	var row strings.Builder
+
+	row.WriteString("<row>")
+	for _, value := range values {
+		if _, ok := value.(float64); !ok {
+			row.WriteString(`<c t="inlineStr"><is><t>`)
+			xml.EscapeText(&row, []byte(formatValue(value)))
+			row.WriteString("</t></is></c>")
+			continue
+		}
+
+		row.WriteString("<c><v>")
+		row.WriteString(formatValue(value))
+		row.WriteString("</v></c>")
+	}
+	row.WriteString("</row>")
+
+	_, err := io.WriteString(xw.sheet, row.String())
+
+	return err
+}
+
+func (xw *xlsxWriter) Close() error {
+	if _, err := io.WriteString(xw.sheet, sheetEnd); err != nil {
+		return err
+	}
+
+	return xw.archive.Close()
+}
@@ -0,0 +1,225 @@
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"net/http"
+	"sort"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/nagymarci/stock-screener/controllers"
+	"github.com/nagymarci/stock-screener/database"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+)
+
+//go:embed schema.graphql
+var schema string
+
+const (
+	maxLimit = 1000
+	maxDepth = 6
+)
+
+//New returns the executable schema resolving through the controller
+func New(controller *controllers.Controller) *graphql.Schema {
+	return graphql.MustParseSchema(schema, &resolver{controller: controller}, graphql.MaxDepth(maxDepth))
+}
+
+// queryError exposes the problem code of the failed field in the extensions of the error
+type queryError struct {
+	problem problem.Problem
+}
+
+func (e *queryError) Error() string {
+	if e.problem.Detail != "" {
+		return e.problem.Detail
+	}
+
+	return e.problem.Title
+}
+
+func (e *queryError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.problem.Code, "status": e.problem.Status}
+}
+
+func wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &queryError{problem: problem.From(err)}
+}
+
+func invalid(code, format string, args ...interface{}) error {
+	return wrap(problem.New(http.StatusBadRequest, code, fmt.Sprintf(format, args...)))
+}
+
+type resolver struct {
+	controller *controllers.Controller
+}
+
+type condition struct {
+	Field string
+	Min   *float64
+	Max   *float64
+}
+
+type stocksArgs struct {
+	Symbols *[]string
+	Where   *[]condition
+	Screens *[]string
+	Sort    *string
+	Limit   int32
+}
+
+func (r *resolver) Stock(ctx context.Context, args struct{ Symbol string }) (*stockResolver, error) {
+	symbol, err := model.ParseSymbol(args.Symbol)
+	if err != nil {
+		return nil, invalid(problem.CodeInvalidSymbol, "%v", err)
+	}
+
+	stocks, err := r.controller.GetStocks(ctx, []model.Symbol{symbol})
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	if len(stocks) == 0 {
+		return nil, nil
+	}
+
+	return r.resolve(stocks)[0], nil
+}
+
+func (r *resolver) Stocks(ctx context.Context, args stocksArgs) ([]*stockResolver, error) {
+	limit := int(args.Limit)
+	if limit < 1 || limit > maxLimit {
+		return nil, invalid(problem.CodeInvalidQuery, "limit must be between 1 and %d", maxLimit)
+	}
+
+	query := database.Query{}
+	if args.Sort != nil && *args.Sort != "" {
+		if err := query.SetSort(*args.Sort); err != nil {
+			return nil, invalid(problem.CodeInvalidQuery, "%v", err)
+		}
+	}
+
+	match, err := filter(args.Where, args.Screens)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.Symbols == nil {
+		stocks, err := r.controller.ScreenStocks(ctx, query, match, limit)
+		if err != nil {
+			return nil, wrap(err)
+		}
+
+		return r.resolve(stocks), nil
+	}
+
+	symbols := make([]model.Symbol, 0, len(*args.Symbols))
+	for _, s := range *args.Symbols {
+		symbol, err := model.ParseSymbol(s)
+		if err != nil {
+			return nil, invalid(problem.CodeInvalidSymbol, "%v", err)
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	stocks, err := r.controller.GetStocks(ctx, symbols)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	result := []model.StockDataInfo{}
+	for _, stock := range stocks {
+		if match(stock) {
+			result = append(result, stock)
+		}
+	}
+
+	sortStocks(result, query)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return r.resolve(result), nil
+}
+
+// resolve wraps the stocks sharing one loader, so the pending updates of the result are fetched together
+func (r *resolver) resolve(stocks []model.StockDataInfo) []*stockResolver {
+	loader := &pendingUpdates{controller: r.controller}
+
+	result := make([]*stockResolver, 0, len(stocks))
+	for _, stock := range stocks {
+		// the tickers are stored in the canonical form, so they always parse
+		symbol, _ := model.ParseSymbol(stock.Ticker)
+		loader.symbols = append(loader.symbols, symbol)
+		result = append(result, &stockResolver{stock: stock, pending: loader})
+	}
+
+	return result
+}
+
+// filter combines the conditions and screens, a stock must match all of them
+func filter(where *[]condition, screenNames *[]string) (func(model.StockDataInfo) bool, error) {
+	var matches []func(model.StockDataInfo) bool
+
+	if where != nil {
+		for _, c := range *where {
+			field, ok := model.LookupField(c.Field)
+			if !ok || !field.Numeric() {
+				return nil, invalid(problem.CodeInvalidQuery, "can't filter by [%s]", c.Field)
+			}
+
+			min, max := c.Min, c.Max
+			matches = append(matches, func(s model.StockDataInfo) bool {
+				value := field.Value(s).(float64)
+				return (min == nil || value >= *min) && (max == nil || value <= *max)
+			})
+		}
+	}
+
+	if screenNames != nil {
+		for _, name := range *screenNames {
+			matches = append(matches, screens[name])
+		}
+	}
+
+	return func(s model.StockDataInfo) bool {
+		for _, match := range matches {
+			if !match(s) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// sortStocks orders the stocks the same way as the database, by the sort field then the ticker
+func sortStocks(stocks []model.StockDataInfo, query database.Query) {
+	field := query.SortField
+	if field.Name == "" {
+		field, _ = model.LookupField("ticker")
+	}
+
+	less := func(a, b model.StockDataInfo) bool {
+		if va, ok := field.Value(a).(float64); ok {
+			vb := field.Value(b).(float64)
+			if va != vb {
+				return va < vb
+			}
+		}
+		return a.Ticker < b.Ticker
+	}
+
+	sort.SliceStable(stocks, func(i, j int) bool {
+		if query.Descending {
+			return less(stocks[j], stocks[i])
+		}
+		return less(stocks[i], stocks[j])
+	})
+}
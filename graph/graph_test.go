@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/model"
+)
+
+func TestScreens(t *testing.T) {
+	stock := model.StockDataInfo{Price: 100, Eps: 10, Dividend: 1}
+	stock.PeRatio5yr.Avg = 12
+	stock.PeRatio5yr.Min = 9
+	stock.DividendYield5yr.Avg = 3
+	stock.DividendYield5yr.Max = 5
+
+	expected := map[string]bool{
+		"PE_BELOW_AVERAGE":    true,
+		"PE_BELOW_MIN":        false,
+		"YIELD_ABOVE_AVERAGE": true,
+		"YIELD_ABOVE_MAX":     false,
+	}
+
+	for name, want := range expected {
+		if got := screens[name](stock); got != want {
+			t.Errorf("screen [%s] expected [%v], got [%v]", name, want, got)
+		}
+	}
+
+	t.Run("missing history matches nothing", func(t *testing.T) {
+		for name, screen := range screens {
+			if screen(model.StockDataInfo{Price: 100, Eps: 10, Dividend: 1}) {
+				t.Errorf("screen [%s] matched a stock without history", name)
+			}
+		}
+	})
+}
+
+func TestValuation(t *testing.T) {
+	stock := model.StockDataInfo{Price: 100, Eps: 10, Dividend: 1}
+	stock.PeRatio5yr.Avg = 20
+	v := &valuationResolver{stock: stock}
+
+	if pe := v.CurrentPe(); pe == nil || *pe != 10 {
+		t.Fatalf("unexpected current pe [%v]", pe)
+	}
+
+	if r := v.PeToAverage(); r == nil || *r != 0.5 {
+		t.Fatalf("unexpected pe to average [%v]", r)
+	}
+
+	if r := v.PeToMin(); r != nil {
+		t.Fatalf("expected no ratio without history, got [%v]", *r)
+	}
+
+	t.Run("negative earnings have no pe", func(t *testing.T) {
+		v := &valuationResolver{stock: model.StockDataInfo{Price: 100, Eps: -2}}
+
+		if pe := v.CurrentPe(); pe != nil {
+			t.Fatalf("expected no pe, got [%v]", *pe)
+		}
+	})
+}
+
+func TestQueryErrors(t *testing.T) {
+	schema := New(nil)
+
+	queries := map[string]string{
+		"invalid_symbol": `{ stock(symbol: "NOT A SYMBOL") { ticker } }`,
+		"invalid_query":  `{ stocks(sort: "source") { ticker } }`,
+	}
+
+	for code, query := range queries {
+		t.Run(code, func(t *testing.T) {
+			response := schema.Exec(context.Background(), query, "", nil)
+
+			if len(response.Errors) != 1 {
+				t.Fatalf("expected one error, got [%v]", response.Errors)
+			}
+
+			if got := response.Errors[0].Extensions["code"]; got != code {
+				t.Fatalf("expected code [%s], got [%v]", code, got)
+			}
+		})
+	}
+
+	t.Run("rejects unknown filter field", func(t *testing.T) {
+		response := schema.Exec(context.Background(), `{ stocks(where: [{field: "ticker", min: 1}]) { ticker } }`, "", nil)
+
+		if len(response.Errors) != 1 || response.Errors[0].Extensions["code"] != "invalid_query" {
+			t.Fatalf("unexpected errors [%v]", response.Errors)
+		}
+	})
+}
+
+func TestPendingUpdate(t *testing.T) {
+	t.Run("is forbidden for viewers", func(t *testing.T) {
+		stock := &stockResolver{stock: model.StockDataInfo{Ticker: "INTC"}, pending: &pendingUpdates{}}
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{Subject: "dashboard", Role: auth.RoleViewer})
+
+		entry, err := stock.PendingUpdate(ctx)
+
+		var queryErr *queryError
+		if entry != nil || !errors.As(err, &queryErr) || queryErr.Extensions()["code"] != "forbidden" {
+			t.Fatalf("expected forbidden, got [%v] [%v]", entry, err)
+		}
+	})
+}
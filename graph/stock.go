@@ -0,0 +1,227 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/controllers"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+)
+
+// pendingUpdates loads the quarantine entries of every stock of a result with one query, on first use
+type pendingUpdates struct {
+	controller *controllers.Controller
+	symbols    []model.Symbol
+	once       sync.Once
+	entries    map[string]model.QuarantineEntry
+	err        error
+}
+
+func (p *pendingUpdates) get(ctx context.Context, ticker string) (*model.QuarantineEntry, error) {
+	p.once.Do(func() {
+		p.entries, p.err = p.controller.GetQuarantinedOf(ctx, p.symbols)
+	})
+
+	if p.err != nil {
+		return nil, wrap(p.err)
+	}
+
+	entry, ok := p.entries[ticker]
+	if !ok {
+		return nil, nil
+	}
+
+	return &entry, nil
+}
+
+type stockResolver struct {
+	stock model.StockDataInfo
+	// nil for the proposed stock of a pending update
+	pending *pendingUpdates
+}
+
+func (s *stockResolver) Ticker() string {
+	return s.stock.Ticker
+}
+
+func (s *stockResolver) Price() float64 {
+	return s.stock.Price
+}
+
+func (s *stockResolver) Eps() float64 {
+	return s.stock.Eps
+}
+
+func (s *stockResolver) Dividend() float64 {
+	return s.stock.Dividend
+}
+
+func (s *stockResolver) LastUpdated() graphql.Time {
+	return graphql.Time{Time: s.stock.LastUpdated}
+}
+
+func (s *stockResolver) Source() string {
+	return s.stock.Source
+}
+
+func (s *stockResolver) Stale() bool {
+	return s.stock.Stale
+}
+
+func (s *stockResolver) Overrides() []*overrideResolver {
+	result := make([]*overrideResolver, 0, len(s.stock.Overrides))
+	for _, o := range s.stock.Overrides {
+		result = append(result, &overrideResolver{override: o})
+	}
+
+	return result
+}
+
+func (s *stockResolver) PeRatio5yr() *peRatioResolver {
+	return &peRatioResolver{stock: s.stock}
+}
+
+func (s *stockResolver) DividendYield5yr() *dividendYieldResolver {
+	return &dividendYieldResolver{stock: s.stock}
+}
+
+func (s *stockResolver) Valuation() *valuationResolver {
+	return &valuationResolver{stock: s.stock}
+}
+
+// PendingUpdate is only shown to admins, like the quarantine of the REST api
+func (s *stockResolver) PendingUpdate(ctx context.Context) (*pendingUpdateResolver, error) {
+	if s.pending == nil {
+		return nil, nil
+	}
+
+	if p, ok := auth.FromContext(ctx); !ok || p.Role < auth.RoleAdmin {
+		return nil, wrap(problem.New(http.StatusForbidden, problem.CodeForbidden, fmt.Sprintf("role [%s] is required", auth.RoleAdmin)))
+	}
+
+	entry, err := s.pending.get(ctx, s.stock.Ticker)
+	if entry == nil || err != nil {
+		return nil, err
+	}
+
+	return &pendingUpdateResolver{entry: *entry}, nil
+}
+
+type peRatioResolver struct {
+	stock model.StockDataInfo
+}
+
+func (p *peRatioResolver) Avg() float64 {
+	return p.stock.PeRatio5yr.Avg
+}
+
+func (p *peRatioResolver) Min() float64 {
+	return p.stock.PeRatio5yr.Min
+}
+
+func (p *peRatioResolver) LastUpdated() graphql.Time {
+	return graphql.Time{Time: p.stock.PeRatio5yr.LastUpdated}
+}
+
+func (p *peRatioResolver) Source() string {
+	return p.stock.PeRatio5yr.Source
+}
+
+type dividendYieldResolver struct {
+	stock model.StockDataInfo
+}
+
+func (d *dividendYieldResolver) Avg() float64 {
+	return d.stock.DividendYield5yr.Avg
+}
+
+func (d *dividendYieldResolver) Max() float64 {
+	return d.stock.DividendYield5yr.Max
+}
+
+func (d *dividendYieldResolver) LastUpdated() graphql.Time {
+	return graphql.Time{Time: d.stock.DividendYield5yr.LastUpdated}
+}
+
+func (d *dividendYieldResolver) Source() string {
+	return d.stock.DividendYield5yr.Source
+}
+
+type overrideResolver struct {
+	override model.Override
+}
+
+func (o *overrideResolver) Field() string {
+	return o.override.Field
+}
+
+func (o *overrideResolver) Value() float64 {
+	return o.override.Value
+}
+
+func (o *overrideResolver) Reason() string {
+	return o.override.Reason
+}
+
+func (o *overrideResolver) Expires() *graphql.Time {
+	if o.override.Expires.IsZero() {
+		return nil
+	}
+
+	return &graphql.Time{Time: o.override.Expires}
+}
+
+func (o *overrideResolver) Created() graphql.Time {
+	return graphql.Time{Time: o.override.Created}
+}
+
+func (o *overrideResolver) CreatedBy() string {
+	return o.override.CreatedBy
+}
+
+type pendingUpdateResolver struct {
+	entry model.QuarantineEntry
+}
+
+func (p *pendingUpdateResolver) Fields() []string {
+	if p.entry.Fields == nil {
+		return []string{}
+	}
+
+	return p.entry.Fields
+}
+
+func (p *pendingUpdateResolver) Proposed() *stockResolver {
+	return &stockResolver{stock: p.entry.Proposed}
+}
+
+func (p *pendingUpdateResolver) Violations() []*violationResolver {
+	result := make([]*violationResolver, 0, len(p.entry.Violations))
+	for _, v := range p.entry.Violations {
+		result = append(result, &violationResolver{violation: v})
+	}
+
+	return result
+}
+
+func (p *pendingUpdateResolver) Created() graphql.Time {
+	return graphql.Time{Time: p.entry.Created}
+}
+
+type violationResolver struct {
+	violation model.Violation
+}
+
+func (v *violationResolver) Field() string {
+	return v.violation.Field
+}
+
+func (v *violationResolver) Message() string {
+	return v.violation.Message
+}
@@ -0,0 +1,64 @@
+package graph
+
+import "github.com/nagymarci/stock-screener/model"
+
+// screens are the named conditions of the Screen enum
+var screens = map[string]func(model.StockDataInfo) bool{
+	"PE_BELOW_AVERAGE": func(s model.StockDataInfo) bool {
+		pe := s.CurrentPe()
+		return pe > 0 && pe < s.PeRatio5yr.Avg
+	},
+	"PE_BELOW_MIN": func(s model.StockDataInfo) bool {
+		pe := s.CurrentPe()
+		return pe > 0 && pe < s.PeRatio5yr.Min
+	},
+	"YIELD_ABOVE_AVERAGE": func(s model.StockDataInfo) bool {
+		return s.DividendYield5yr.Avg > 0 && s.CurrentDividendYield() > s.DividendYield5yr.Avg
+	},
+	"YIELD_ABOVE_MAX": func(s model.StockDataInfo) bool {
+		return s.DividendYield5yr.Max > 0 && s.CurrentDividendYield() > s.DividendYield5yr.Max
+	},
+}
+
+// ratio returns value / base, nil when either is missing
+func ratio(value, base float64) *float64 {
+	if value <= 0 || base <= 0 {
+		return nil
+	}
+
+	result := value / base
+	return &result
+}
+
+type valuationResolver struct {
+	stock model.StockDataInfo
+}
+
+func (v *valuationResolver) CurrentPe() *float64 {
+	if v.stock.Eps <= 0 {
+		return nil
+	}
+
+	pe := v.stock.CurrentPe()
+	return &pe
+}
+
+func (v *valuationResolver) CurrentDividendYield() float64 {
+	return v.stock.CurrentDividendYield()
+}
+
+func (v *valuationResolver) PeToAverage() *float64 {
+	return ratio(v.stock.CurrentPe(), v.stock.PeRatio5yr.Avg)
+}
+
+func (v *valuationResolver) PeToMin() *float64 {
+	return ratio(v.stock.CurrentPe(), v.stock.PeRatio5yr.Min)
+}
+
+func (v *valuationResolver) DividendYieldToAverage() *float64 {
+	return ratio(v.stock.CurrentDividendYield(), v.stock.DividendYield5yr.Avg)
+}
+
+func (v *valuationResolver) DividendYieldToMax() *float64 {
+	return ratio(v.stock.CurrentDividendYield(), v.stock.DividendYield5yr.Max)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nagymarci/stock-screener/controllers"
+	"github.com/nagymarci/stock-screener/problem"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+)
+
+// GetQuarantineHandler lists the provider updates waiting for review
+func GetQuarantineHandler(router *mux.Router, controller *controllers.Controller) {
+	router.HandleFunc("/quarantine", func(w http.ResponseWriter, r *http.Request) {
+		result, err := controller.GetQuarantined(r.Context())
+
+		if err != nil {
+			logrus.Errorln(err)
+			problem.Write(w, r, err)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+// ApproveQuarantineHandler applies the quarantined update of the symbol
+func ApproveQuarantineHandler(router *mux.Router, controller *controllers.Controller) {
+	router.HandleFunc("/quarantine/{symbol}/approve", func(w http.ResponseWriter, r *http.Request) {
+		symbol, ok := symbolVar(w, r)
+		if !ok {
+			return
+		}
+
+		log := logrus.WithField("symbol", symbol)
+
+		err := controller.ApproveQuarantined(r.Context(), symbol)
+
+		if err != nil {
+			log.Errorln(err)
+			problem.Write(w, r, err)
+			return
+		}
+
+		log.Infoln("Quarantined update approved")
+
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodPost)
+}
+
+// RejectQuarantineHandler drops the quarantined update of the symbol
+func RejectQuarantineHandler(router *mux.Router, controller *controllers.Controller) {
+	router.HandleFunc("/quarantine/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		symbol, ok := symbolVar(w, r)
+		if !ok {
+			return
+		}
+
+		log := logrus.WithField("symbol", symbol)
+
+		err := controller.RejectQuarantined(r.Context(), symbol)
+
+		if err != nil {
+			log.Errorln(err)
+			problem.Write(w, r, err)
+			return
+		}
+
+		log.Infoln("Quarantined update rejected")
+
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodDelete)
+}
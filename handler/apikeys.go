@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+)
+
+// the old key of a rotation keeps working for this long unless told otherwise
+const defaultGracePeriod = 24 * time.Hour
+
+type keyRequest struct {
+	Name   string         `json:"name"`
+	Scopes []string       `json:"scopes"`
+	TTL    model.Duration `json:"ttl"`
+}
+
+// GetAPIKeysHandler lists the issued api keys without their secrets
+func GetAPIKeysHandler(router *mux.Router, keys *auth.Keys) {
+	router.HandleFunc("/apikeys", func(w http.ResponseWriter, r *http.Request) {
+		result, err := keys.List(r.Context())
+
+		if err != nil {
+			logrus.Errorln(err)
+			problem.Write(w, r, err)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+// IssueAPIKeyHandler creates a key, the response holds the only copy of the secret
+func IssueAPIKeyHandler(router *mux.Router, keys *auth.Keys) {
+	router.HandleFunc("/apikeys", func(w http.ResponseWriter, r *http.Request) {
+		var request keyRequest
+
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(&request); err != nil {
+			problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidRequest, err))
+			return
+		}
+
+		issued, err := keys.Issue(r.Context(), auth.KeyRequest{Name: request.Name, Scopes: request.Scopes, TTL: time.Duration(request.TTL)}, actor(r))
+
+		if err != nil {
+			handleKeyError(w, r, err)
+			return
+		}
+
+		logrus.WithField("key", issued.Key.ID).WithField("name", issued.Key.Name).Infoln("API key issued")
+
+		stockHttp.HandleJSONResponse(issued, w, http.StatusCreated)
+	}).Methods(http.MethodPost)
+}
+
+// RotateAPIKeyHandler replaces the key, the old one expires after the gracePeriod query parameter
+func RotateAPIKeyHandler(router *mux.Router, keys *auth.Keys) {
+	router.HandleFunc("/apikeys/{id}/rotate", func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		gracePeriod := defaultGracePeriod
+		if value := r.URL.Query().Get("gracePeriod"); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed < 0 {
+				problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidQuery, "gracePeriod must be a duration like 24h"))
+				return
+			}
+			gracePeriod = parsed
+		}
+
+		issued, err := keys.Rotate(r.Context(), id, gracePeriod, actor(r))
+
+		if err != nil {
+			handleKeyError(w, r, err)
+			return
+		}
+
+		logrus.WithField("key", id).WithField("replacement", issued.Key.ID).Infoln("API key rotated")
+
+		stockHttp.HandleJSONResponse(issued, w, http.StatusCreated)
+	}).Methods(http.MethodPost)
+}
+
+// RevokeAPIKeyHandler disables the key immediately
+func RevokeAPIKeyHandler(router *mux.Router, keys *auth.Keys) {
+	router.HandleFunc("/apikeys/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		if err := keys.Revoke(r.Context(), id); err != nil {
+			handleKeyError(w, r, err)
+			return
+		}
+
+		logrus.WithField("key", id).Infoln("API key revoked")
+
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodDelete)
+}
+
+func handleKeyError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidKeyRequest):
+		problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidAPIKeyRequest, err))
+	case errors.Is(err, mongo.ErrNoDocuments):
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeAPIKeyNotFound, "api key not found"))
+	default:
+		logrus.Errorln(err)
+		problem.Write(w, r, err)
+	}
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/nagymarci/stock-screener/problem"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+)
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQLHandler executes the queries against the schema, field errors are reported in the response body
+func GraphQLHandler(router *mux.Router, schema *graphql.Schema) {
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		var request graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Query == "" {
+			problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "body must be a json object with a query"))
+			return
+		}
+
+		response := schema.Exec(r.Context(), request.Query, request.OperationName, request.Variables)
+
+		stockHttp.HandleJSONResponse(response, w, http.StatusOK)
+	}).Methods(http.MethodPost)
+}
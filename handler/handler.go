@@ -1,13 +1,22 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/gorilla/mux"
 
 	"github.com/nagymarci/stock-screener/controllers"
+	"github.com/nagymarci/stock-screener/export"
+	"github.com/nagymarci/stock-screener/importer"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+	"github.com/nagymarci/stock-screener/ratelimit"
 
 	stockHttp "github.com/nagymarci/stock-commons/http"
 )
@@ -15,34 +24,47 @@ import (
 // RegisterStock registers a stock symbol to the watchlist to evaluate it
 func RegisterStockHandler(router *mux.Router, controller *controllers.Controller) {
 	router.HandleFunc("/{symbol}", func(w http.ResponseWriter, r *http.Request) {
-		symbol := mux.Vars(r)["symbol"]
+		symbol, ok := symbolVar(w, r)
+		if !ok {
+			return
+		}
 
 		log := logrus.WithField("symbol", symbol)
 
-		err := controller.RegisterStock(symbol)
+		err := controller.RegisterStock(r.Context(), symbol)
+
+		var quotaErr *ratelimit.QuotaError
+		if errors.As(err, &quotaErr) {
+			log.Warnln(err)
+			ratelimit.TooManyRequests(w, r, problem.Wrap(http.StatusTooManyRequests, problem.CodeQuotaExceeded, err), quotaErr.RetryAfter)
+			return
+		}
 
 		if err != nil {
 			log.Errorln(err)
-			stockHttp.HandleError(err, w)
+			problem.Write(w, r, err)
 			return
 		}
 
 		w.WriteHeader(http.StatusOK)
-	}).Methods(http.MethodPost, http.MethodOptions)
+	}).Methods(http.MethodPost)
 }
 
 // GetStockInfo returns the information of a stock symbol
 func GetStockInfoHandler(router *mux.Router, controller *controllers.Controller) {
 	router.HandleFunc("/{symbol}", func(w http.ResponseWriter, r *http.Request) {
-		symbol := mux.Vars(r)["symbol"]
+		symbol, ok := symbolVar(w, r)
+		if !ok {
+			return
+		}
 
 		log := logrus.WithField("symbol", symbol)
 
-		result, err := controller.GetStockInfo(symbol)
+		result, err := controller.GetStockInfo(r.Context(), symbol)
 
 		if err != nil {
 			log.Errorln(err)
-			stockHttp.HandleError(err, w)
+			problem.Write(w, r, err)
 			return
 		}
 
@@ -50,16 +72,193 @@ func GetStockInfoHandler(router *mux.Router, controller *controllers.Controller)
 	}).Methods(http.MethodGet)
 }
 
-// GetAllStocks returns the information of all of the stocks
+// GetStockChangesHandler returns the audit trail of the stock, newest first, optionally of one field
+func GetStockChangesHandler(router *mux.Router, controller *controllers.Controller) {
+	router.HandleFunc("/{symbol}/changes", func(w http.ResponseWriter, r *http.Request) {
+		symbol, ok := symbolVar(w, r)
+		if !ok {
+			return
+		}
+
+		field, limit, err := parseChangesQuery(r.URL.Query())
+
+		if err != nil {
+			problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidQuery, err))
+			return
+		}
+
+		log := logrus.WithField("symbol", symbol)
+
+		result, err := controller.GetChanges(r.Context(), symbol, field, limit)
+
+		if err != nil {
+			log.Errorln(err)
+			problem.Write(w, r, err)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+// GetAllStocks returns a page of stocks, sorted and projected as requested
 func GetAllStocksHandler(router *mux.Router, controller *controllers.Controller) {
 	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		query, err := parseStockQuery(r.URL.Query())
+
+		if err != nil {
+			problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidQuery, err))
+			return
+		}
+
+		page, err := controller.GetAllStocks(r.Context(), query)
+
+		if err != nil {
+			logrus.Errorln(err)
+			problem.Write(w, r, err)
+			return
+		}
+
+		if page.NextCursor != "" {
+			w.Header().Set("Link", nextPageLink(r, page.NextCursor))
+		}
+
+		if len(query.Fields) == 0 {
+			result := page.Stocks
+			if result == nil {
+				result = []model.StockDataInfo{}
+			}
+			stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+			return
+		}
 
-		result := controller.GetAllStocks()
+		result := make([]map[string]interface{}, 0, len(page.Stocks))
+		for _, stock := range page.Stocks {
+			result = append(result, model.Project(stock, query.Fields))
+		}
 
 		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
 	}).Methods(http.MethodGet)
 }
 
+// ExportStocksHandler streams every stock in the requested format
+func ExportStocksHandler(router *mux.Router, controller *controllers.Controller) {
+	router.HandleFunc("/export", func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = export.FormatCSV
+		}
+
+		if !export.Supported(format) {
+			problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeUnsupportedFormat, "unsupported format ["+format+"]"))
+			return
+		}
+
+		query, err := parseStockQuery(r.URL.Query())
+
+		if err != nil {
+			problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidQuery, err))
+			return
+		}
+
+		query.Limit = 0
+		query.Cursor = ""
+
+		fields := query.Fields
+		if len(fields) == 0 {
+			fields = model.Fields()
+		}
+
+		log := logrus.WithField("format", format)
+
+		// the writer is created with the first stock, so errors of the query can still change the status
+		var writer export.Writer
+
+		err = controller.ExportStocks(r.Context(), query, func(stock model.StockDataInfo) error {
+			if writer == nil {
+				if writer, err = newExportWriter(w, format, fields); err != nil {
+					return err
+				}
+			}
+
+			return writer.Write(stock)
+		})
+
+		if err == nil && writer == nil {
+			writer, err = newExportWriter(w, format, fields)
+		}
+
+		if err != nil {
+			log.Errorln(err)
+			if writer == nil {
+				problem.Write(w, r, err)
+			}
+			return
+		}
+
+		if err := writer.Close(); err != nil {
+			log.Errorln(err)
+		}
+	}).Methods(http.MethodGet)
+}
+
+func newExportWriter(w http.ResponseWriter, format string, fields []model.Field) (export.Writer, error) {
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", "attachment; filename=\"stocks."+format+"\"")
+
+	return export.New(format, w, fields)
+}
+
+// ImportStocksHandler registers a csv watchlist or stores csv stock data, the file is read from the
+// multipart field "file" or from the request body
+func ImportStocksHandler(router *mux.Router, controller *controllers.Controller) {
+	router.HandleFunc("/import", func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+		var upload io.Reader = r.Body
+
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			file, _, err := r.FormFile("file")
+
+			if err != nil {
+				problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidImport, err))
+				return
+			}
+			defer file.Close()
+
+			upload = file
+		}
+
+		data, err := importer.Parse(upload)
+
+		if err != nil {
+			logrus.Warnln(err)
+			problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidImport, err))
+			return
+		}
+
+		dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+
+		report := controller.ImportStocks(r.Context(), data, dryRun)
+
+		logrus.WithField("dryRun", dryRun).Infof("Imported [%d] of [%d] rows\n", report.Imported, report.Rows)
+
+		stockHttp.HandleJSONResponse(report, w, http.StatusOK)
+	}).Methods(http.MethodPost)
+}
+
+// symbolVar parses the symbol of the route, invalid symbols are answered with bad request
+func symbolVar(w http.ResponseWriter, r *http.Request) (model.Symbol, bool) {
+	symbol, err := model.ParseSymbol(mux.Vars(r)["symbol"])
+
+	if err != nil {
+		problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidSymbol, err))
+		return symbol, false
+	}
+
+	return symbol, true
+}
+
 /*
 //UpdateAll updates all stocks in the database
 func UpdateAll(w http.ResponseWriter, r *http.Request) {
@@ -74,15 +273,19 @@ func UpdateAll(w http.ResponseWriter, r *http.Request) {
 //DeleteStock deletes the given stock from the database
 func DeleteStockHandler(router *mux.Router, controller *controllers.Controller) {
 	router.HandleFunc("/{symbol}", func(w http.ResponseWriter, r *http.Request) {
-		symbol := mux.Vars(r)["symbol"]
+		symbol, ok := symbolVar(w, r)
+		if !ok {
+			return
+		}
 
 		log := logrus.WithField("symbol", symbol)
 
-		err := controller.DeleteStock(symbol)
+		err := controller.DeleteStock(r.Context(), symbol)
 
 		if err != nil {
-			log.Println(err)
-			stockHttp.HandleError(err, w)
+			log.Errorln(err)
+			problem.Write(w, r, err)
+			return
 		}
 
 		w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nagymarci/stock-screener/health"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+)
+
+//HealthHandler reports that the process is alive, it doesn't depend on anything
+func HealthHandler(router *mux.Router) {
+	router.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		stockHttp.HandleJSONResponse(health.Report{Status: health.StatusOK}, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+//ReadyHandler reports if the dependencies are available to serve traffic
+func ReadyHandler(router *mux.Router, checker *health.Checker) {
+	router.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Check(r.Context())
+
+		if !report.Ready() {
+			logrus.WithField("checks", report.Checks).Warnf("not ready: [%s]", report.Status)
+			stockHttp.HandleJSONResponse(report, w, http.StatusServiceUnavailable)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(report, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nagymarci/stock-screener/controllers"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+	"github.com/nagymarci/stock-screener/watch"
+)
+
+const (
+	// changes queued for a connection before it's closed as too slow
+	liveBuffer     = 64
+	maxLiveSymbols = 1000
+	maxLiveMessage = 64 << 10
+	pingInterval   = 30 * time.Second
+	pongWait       = 2 * pingInterval
+	writeWait      = 10 * time.Second
+)
+
+// types of the messages of the live connection
+const (
+	liveSubscribe   = "subscribe"
+	liveUnsubscribe = "unsubscribe"
+	liveSubscribed  = "subscribed"
+	liveSnapshot    = "snapshot"
+	liveChange      = "change"
+	liveError       = "error"
+)
+
+type liveRequest struct {
+	Type    string   `json:"type"`
+	Symbols []string `json:"symbols"`
+}
+
+// liveSubscription lists every subscribed symbol, even when there are none
+type liveSubscription struct {
+	Type    string   `json:"type"`
+	Symbols []string `json:"symbols"`
+}
+
+type liveMessage struct {
+	Type    string                `json:"type"`
+	Stocks  []model.StockDataInfo `json:"stocks,omitempty"`
+	ID      string                `json:"id,omitempty"`
+	Kind    watch.Kind            `json:"kind,omitempty"`
+	Ticker  string                `json:"ticker,omitempty"`
+	Time    *time.Time            `json:"time,omitempty"`
+	Changes []model.FieldChange   `json:"changes,omitempty"`
+	Code    string                `json:"code,omitempty"`
+	Detail  string                `json:"detail,omitempty"`
+}
+
+type stockLoader interface {
+	GetStocks(ctx context.Context, symbols []model.Symbol) ([]model.StockDataInfo, error)
+}
+
+// LiveStocksHandler upgrades to a websocket sending the changed fields of the subscribed stocks,
+// browsers on other origins are accepted like the CORS requests
+func LiveStocksHandler(router *mux.Router, controller *controllers.Controller, changes *watch.Hub, originAllowed func(string) bool) {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: checkOrigin(originAllowed),
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			problem.Write(w, r, problem.Wrap(status, problem.CodeInvalidRequest, reason))
+		},
+	}
+
+	router.HandleFunc("/live", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			logrus.Warnln(err)
+			return
+		}
+
+		newLiveConnection(conn, controller, changes).serve(r.Context())
+	}).Methods(http.MethodGet)
+}
+
+// checkOrigin accepts clients without origin, the same origin and the origins allowed by the check
+func checkOrigin(originAllowed func(string) bool) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+
+		return originAllowed != nil && originAllowed(origin)
+	}
+}
+
+// liveConnection keeps the last sent state of the subscribed stocks, the changes are sent as differences to it
+type liveConnection struct {
+	conn      *websocket.Conn
+	stocks    stockLoader
+	watchlist *watch.Subscription
+	known     map[string]model.StockDataInfo
+}
+
+func newLiveConnection(conn *websocket.Conn, stocks stockLoader, changes *watch.Hub) *liveConnection {
+	return &liveConnection{
+		conn:      conn,
+		stocks:    stocks,
+		watchlist: changes.Watchlist(liveBuffer),
+		known:     map[string]model.StockDataInfo{},
+	}
+}
+
+// serve writes from this goroutine only, the requests are read by another one
+func (l *liveConnection) serve(ctx context.Context) {
+	defer l.conn.Close()
+	defer l.watchlist.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	requests := make(chan []byte)
+	go l.read(requests, done)
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+
+		select {
+		case request, open := <-requests:
+			if !open {
+				return
+			}
+			err = l.handle(ctx, request)
+		case change, open := <-l.watchlist.Changes():
+			if !open {
+				if errors.Is(l.watchlist.Err(), watch.ErrClosed) {
+					l.close(websocket.CloseGoingAway, "server is shutting down")
+					return
+				}
+				logrus.Warnln(l.watchlist.Err())
+				l.close(websocket.CloseTryAgainLater, "too many changes queued, subscribe again")
+				return
+			}
+			err = l.send(change)
+		case <-ping.C:
+			err = l.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+		}
+
+		if err != nil {
+			logrus.Debugln(err)
+			return
+		}
+	}
+}
+
+// read passes the messages of the client until the connection fails or misses the pongs
+func (l *liveConnection) read(requests chan<- []byte, done <-chan struct{}) {
+	defer close(requests)
+
+	l.conn.SetReadLimit(maxLiveMessage)
+	l.conn.SetReadDeadline(time.Now().Add(pongWait))
+	l.conn.SetPongHandler(func(string) error {
+		return l.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := l.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		select {
+		case requests <- data:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (l *liveConnection) handle(ctx context.Context, data []byte) error {
+	var request liveRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return l.fail(problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "messages must be json objects with a type"))
+	}
+
+	symbols := make([]model.Symbol, 0, len(request.Symbols))
+	tickers := make([]string, 0, len(request.Symbols))
+	for _, value := range request.Symbols {
+		symbol, err := model.ParseSymbol(value)
+		if err != nil {
+			return l.fail(problem.Wrap(http.StatusBadRequest, problem.CodeInvalidSymbol, err))
+		}
+		symbols = append(symbols, symbol)
+		tickers = append(tickers, symbol.String())
+	}
+
+	switch request.Type {
+	case liveSubscribe:
+		if len(l.watchlist.Tickers())+len(tickers) > maxLiveSymbols {
+			return l.fail(problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, fmt.Sprintf("at most %d symbols can be subscribed", maxLiveSymbols)))
+		}
+
+		// changes written while the snapshot is loaded are queued, they are sent as differences to it
+		l.watchlist.Add(tickers...)
+
+		stocks, err := l.stocks.GetStocks(ctx, symbols)
+		if err != nil {
+			logrus.Errorln(err)
+			return l.fail(err)
+		}
+
+		for _, stock := range stocks {
+			l.known[stock.Ticker] = stock
+		}
+
+		if err := l.write(liveSubscription{Type: liveSubscribed, Symbols: l.watchlist.Tickers()}); err != nil {
+			return err
+		}
+
+		return l.write(liveMessage{Type: liveSnapshot, Stocks: stocks})
+	case liveUnsubscribe:
+		l.watchlist.Remove(tickers...)
+		for _, ticker := range tickers {
+			delete(l.known, ticker)
+		}
+
+		return l.write(liveSubscription{Type: liveSubscribed, Symbols: l.watchlist.Tickers()})
+	default:
+		return l.fail(problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "unknown message type ["+request.Type+"]"))
+	}
+}
+
+func (l *liveConnection) send(change watch.Change) error {
+	ticker := change.Stock.Ticker
+	message := liveMessage{Type: liveChange, ID: change.ID, Kind: change.Kind, Ticker: ticker, Time: &change.Time}
+
+	if change.Kind == watch.KindDeleted {
+		delete(l.known, ticker)
+		return l.write(message)
+	}
+
+	message.Changes = model.Diff(l.known[ticker], change.Stock)
+	l.known[ticker] = change.Stock
+
+	// the snapshot may already hold the change
+	if change.Kind == watch.KindUpdated && len(message.Changes) == 0 {
+		return nil
+	}
+
+	return l.write(message)
+}
+
+// fail tells the client about the invalid request, the connection stays open
+func (l *liveConnection) fail(err error) error {
+	p := problem.From(err)
+	return l.write(liveMessage{Type: liveError, Code: p.Code, Detail: p.Detail})
+}
+
+func (l *liveConnection) write(message interface{}) error {
+	l.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return l.conn.WriteJSON(message)
+}
+
+func (l *liveConnection) close(code int, reason string) {
+	l.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/watch"
+)
+
+type fakeLoader map[string]model.StockDataInfo
+
+func (f fakeLoader) GetStocks(ctx context.Context, symbols []model.Symbol) ([]model.StockDataInfo, error) {
+	result := []model.StockDataInfo{}
+	for _, symbol := range symbols {
+		if stock, ok := f[symbol.String()]; ok {
+			result = append(result, stock)
+		}
+	}
+
+	return result, nil
+}
+
+// received is any message of the server
+type received struct {
+	liveMessage
+	Symbols []string `json:"symbols"`
+}
+
+func TestLiveStocks(t *testing.T) {
+	changes := watch.NewHub()
+	stocks := fakeLoader{"INTC": {Ticker: "INTC", Price: 49.28, Eps: 5.43}}
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		newLiveConnection(conn, stocks, changes).serve(context.Background())
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	receive := func(t *testing.T) received {
+		var message received
+		if err := conn.ReadJSON(&message); err != nil {
+			t.Fatal(err)
+		}
+		return message
+	}
+
+	conn.WriteJSON(liveRequest{Type: liveSubscribe, Symbols: []string{"intc", "msft"}})
+
+	if message := receive(t); message.Type != liveSubscribed || strings.Join(message.Symbols, ",") != "INTC,MSFT" {
+		t.Fatalf("unexpected message [%v]", message)
+	}
+
+	if message := receive(t); message.Type != liveSnapshot || len(message.Stocks) != 1 {
+		t.Fatalf("unexpected snapshot [%v]", message)
+	}
+
+	t.Run("sends the changed fields", func(t *testing.T) {
+		changes.Publish(watch.KindUpdated, model.StockDataInfo{Ticker: "INTC", Price: 50, Eps: 5.43})
+
+		message := receive(t)
+		if message.Type != liveChange || message.Ticker != "INTC" || len(message.Changes) != 1 || message.Changes[0] != (model.FieldChange{Field: "price", Old: 49.28, New: 50}) {
+			t.Fatalf("unexpected change [%v]", message)
+		}
+	})
+
+	t.Run("stops after unsubscribe", func(t *testing.T) {
+		conn.WriteJSON(liveRequest{Type: liveUnsubscribe, Symbols: []string{"INTC"}})
+
+		if message := receive(t); message.Type != liveSubscribed || strings.Join(message.Symbols, ",") != "MSFT" {
+			t.Fatalf("unexpected message [%v]", message)
+		}
+
+		changes.Publish(watch.KindUpdated, model.StockDataInfo{Ticker: "INTC", Price: 51})
+		changes.Publish(watch.KindRegistered, model.StockDataInfo{Ticker: "MSFT", Price: 200})
+
+		message := receive(t)
+		if message.Kind != watch.KindRegistered || message.Ticker != "MSFT" {
+			t.Fatalf("unexpected change [%v]", message)
+		}
+	})
+
+	t.Run("reports invalid requests", func(t *testing.T) {
+		conn.WriteJSON(liveRequest{Type: liveSubscribe, Symbols: []string{"NOT A SYMBOL"}})
+
+		if message := receive(t); message.Type != liveError || message.Code != "invalid_symbol" {
+			t.Fatalf("unexpected message [%v]", message)
+		}
+	})
+}
+
+func TestLiveStocksShutdown(t *testing.T) {
+	changes := watch.NewHub()
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		newLiveConnection(conn, fakeLoader{}, changes).serve(context.Background())
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	// the watchlist is made after the upgrade
+	time.Sleep(10 * time.Millisecond)
+	changes.Close()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("expected going away, got [%v]", err)
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	check := checkOrigin(func(origin string) bool { return origin == "https://dashboard.example.com" })
+
+	tests := map[string]bool{
+		"":                              true,
+		"http://api.example.com":        true,
+		"https://dashboard.example.com": true,
+		"https://evil.example.com":      false,
+	}
+
+	for origin, expected := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://api.example.com/v1/stocks/live", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+
+		if check(r) != expected {
+			t.Errorf("[%s]: expected [%v]", origin, expected)
+		}
+	}
+}
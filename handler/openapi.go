@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nagymarci/stock-screener/openapi"
+)
+
+// OpenAPIHandler serves the OpenAPI document of the api
+func OpenAPIHandler(router *mux.Router) {
+	router.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openapi.Spec())
+	}).Methods(http.MethodGet)
+}
+
+// SwaggerUIHandler serves the page browsing the OpenAPI document
+func SwaggerUIHandler(router *mux.Router) {
+	router.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(openapi.SwaggerUI())
+	}).Methods(http.MethodGet)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nagymarci/stock-screener/controllers"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+)
+
+type overrideRequest struct {
+	Field   string     `json:"field"`
+	Value   *float64   `json:"value"`
+	Reason  string     `json:"reason"`
+	Expires *time.Time `json:"expires"`
+}
+
+// SetOverridesHandler replaces the overrides of the stock, an empty list removes every override
+func SetOverridesHandler(router *mux.Router, controller *controllers.Controller) {
+	router.HandleFunc("/{symbol}/overrides", func(w http.ResponseWriter, r *http.Request) {
+		symbol, ok := symbolVar(w, r)
+		if !ok {
+			return
+		}
+
+		overrides, err := decodeOverrides(r)
+
+		if err != nil {
+			problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidRequest, err))
+			return
+		}
+
+		log := logrus.WithField("symbol", symbol)
+
+		result, err := controller.SetOverrides(r.Context(), symbol, overrides)
+
+		if err != nil {
+			log.Errorln(err)
+			problem.Write(w, r, err)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodPut)
+}
+
+func decodeOverrides(r *http.Request) ([]model.Override, error) {
+	var request []overrideRequest
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&request); err != nil {
+		return nil, err
+	}
+
+	result := make([]model.Override, 0, len(request))
+	for _, o := range request {
+		if o.Value == nil {
+			return nil, errors.New("the override of [" + o.Field + "] has no value")
+		}
+
+		override := model.Override{Field: o.Field, Value: *o.Value, Reason: o.Reason}
+		if o.Expires != nil {
+			override.Expires = *o.Expires
+		}
+
+		result = append(result, override)
+	}
+
+	return result, nil
+}
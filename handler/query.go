@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nagymarci/stock-screener/database"
+	"github.com/nagymarci/stock-screener/model"
+)
+
+const (
+	maxPageLimit        = 1000
+	maxImportSize       = 10 << 20
+	defaultChangesLimit = 100
+)
+
+//parseStockQuery builds the database query from the limit, cursor, sort and fields parameters
+func parseStockQuery(values url.Values) (database.Query, error) {
+	query := database.Query{Cursor: values.Get("cursor")}
+
+	if limit := values.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > maxPageLimit {
+			return query, fmt.Errorf("limit must be a number between 1 and %d", maxPageLimit)
+		}
+		query.Limit = l
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		if err := query.SetSort(sort); err != nil {
+			return query, err
+		}
+	}
+
+	if fields := values.Get("fields"); fields != "" {
+		selected, unknown := model.ExpandFields(strings.Split(fields, ","))
+		if len(unknown) > 0 {
+			return query, fmt.Errorf("unknown fields [%s]", strings.Join(unknown, ","))
+		}
+		query.Fields = selected
+	}
+
+	return query, nil
+}
+
+//parseChangesQuery reads the field and limit parameters of the audit trail, only stored numbers have changes
+func parseChangesQuery(values url.Values) (string, int, error) {
+	field := values.Get("field")
+
+	if field != "" {
+		if f, ok := model.LookupField(field); !ok || f.Set == nil {
+			return "", 0, fmt.Errorf("unknown field [%s], changes are recorded for the stored numbers", field)
+		}
+	}
+
+	limit := defaultChangesLimit
+
+	if value := values.Get("limit"); value != "" {
+		l, err := strconv.Atoi(value)
+		if err != nil || l < 1 || l > maxPageLimit {
+			return "", 0, fmt.Errorf("limit must be a number between 1 and %d", maxPageLimit)
+		}
+		limit = l
+	}
+
+	return field, limit, nil
+}
+
+//nextPageLink returns the Link header value pointing to the page after the current request
+func nextPageLink(r *http.Request, cursor string) string {
+	next := *r.URL
+	values := next.Query()
+	values.Set("cursor", cursor)
+	next.RawQuery = values.Encode()
+
+	return fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI())
+}
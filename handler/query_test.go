@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestParseStockQuery(t *testing.T) {
+	t.Run("parses limit, cursor, sort and fields", func(t *testing.T) {
+		values := url.Values{}
+		values.Set("limit", "20")
+		values.Set("cursor", "abc")
+		values.Set("sort", "-currentPe")
+		values.Set("fields", "ticker,price,peRatio5yr")
+
+		query, err := parseStockQuery(values)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if query.Limit != 20 || query.Cursor != "abc" {
+			t.Fatalf("unexpected paging [%d] [%s]", query.Limit, query.Cursor)
+		}
+
+		if query.SortField.Name != "currentPe" || !query.Descending {
+			t.Fatalf("unexpected sort [%s]", query.SortKey())
+		}
+
+		if len(query.Fields) != 6 {
+			t.Fatalf("expected 6 fields, got [%d]", len(query.Fields))
+		}
+	})
+	t.Run("rejects unknown sort field", func(t *testing.T) {
+		values := url.Values{}
+		values.Set("sort", "name")
+
+		_, err := parseStockQuery(values)
+
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+	})
+	t.Run("rejects limit out of range", func(t *testing.T) {
+		values := url.Values{}
+		values.Set("limit", "0")
+
+		_, err := parseStockQuery(values)
+
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+	})
+	t.Run("next page link keeps the other parameters", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/stocks?limit=2&fields=ticker", nil)
+
+		link := nextPageLink(req, "xyz")
+
+		if link != "</stocks?cursor=xyz&fields=ticker&limit=2>; rel=\"next\"" {
+			t.Fatalf("unexpected link [%s]", link)
+		}
+	})
+}
+
+func TestParseChangesQuery(t *testing.T) {
+	t.Run("defaults to every field", func(t *testing.T) {
+		field, limit, err := parseChangesQuery(url.Values{})
+
+		if err != nil || field != "" || limit != defaultChangesLimit {
+			t.Fatalf("unexpected [%s] [%d] [%v]", field, limit, err)
+		}
+	})
+	t.Run("rejects fields without changes", func(t *testing.T) {
+		for _, field := range []string{"ticker", "currentPe", "name"} {
+			values := url.Values{}
+			values.Set("field", field)
+
+			if _, _, err := parseChangesQuery(values); err == nil {
+				t.Fatalf("expected error for [%s]", field)
+			}
+		}
+	})
+}
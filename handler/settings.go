@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+	"github.com/nagymarci/stock-screener/settings"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+)
+
+const defaultHistoryLimit = 50
+
+// GetSettingsHandler returns the runtime settings in effect
+func GetSettingsHandler(router *mux.Router, manager *settings.Manager) {
+	router.HandleFunc("/settings", func(w http.ResponseWriter, r *http.Request) {
+		stockHttp.HandleJSONResponse(manager.Current(), w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+// UpdateSettingsHandler changes the runtime settings, fields missing from the body are kept
+func UpdateSettingsHandler(router *mux.Router, manager *settings.Manager) {
+	router.HandleFunc("/settings", func(w http.ResponseWriter, r *http.Request) {
+		updated := manager.Current()
+
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(&updated); err != nil {
+			problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidRequest, err))
+			return
+		}
+
+		result, err := manager.Apply(r.Context(), updated, model.SettingsSourceAPI, actor(r))
+
+		if _, ok := err.(*settings.ValidationError); ok {
+			problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidSettings, err))
+			return
+		}
+
+		if err != nil {
+			logrus.Errorln(err)
+			problem.Write(w, r, err)
+			return
+		}
+
+		logrus.WithField("settings", result).Infoln("Settings changed")
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodPatch)
+}
+
+// GetSettingsHistoryHandler returns the audit trail of the settings changes
+func GetSettingsHistoryHandler(router *mux.Router, manager *settings.Manager) {
+	router.HandleFunc("/settings/history", func(w http.ResponseWriter, r *http.Request) {
+		limit := int64(defaultHistoryLimit)
+
+		if value := r.URL.Query().Get("limit"); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed < 1 {
+				problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidQuery, "limit must be a positive number"))
+				return
+			}
+			limit = parsed
+		}
+
+		result, err := manager.History(r.Context(), limit)
+
+		if err != nil {
+			logrus.Errorln(err)
+			problem.Write(w, r, err)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+// actor identifies the caller in the audit trail
+func actor(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return p.Subject
+	}
+
+	return r.RemoteAddr
+}
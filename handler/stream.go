@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+	"github.com/nagymarci/stock-screener/watch"
+)
+
+const (
+	// changes queued for a client before its stream is ended, it resumes with Last-Event-ID
+	streamBuffer = 64
+	// comments sent on idle streams, so proxies don't close them
+	heartbeatInterval = 15 * time.Second
+)
+
+// StreamStocksHandler pushes the written stocks as server-sent events, the event id resumes the stream
+func StreamStocksHandler(router *mux.Router, changes *watch.Hub) {
+	router.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		var tickers []string
+		if symbols := r.URL.Query().Get("symbols"); symbols != "" {
+			for _, value := range strings.Split(symbols, ",") {
+				symbol, err := model.ParseSymbol(value)
+				if err != nil {
+					problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidSymbol, err))
+					return
+				}
+				tickers = append(tickers, symbol.String())
+			}
+		}
+
+		subscription, expired := subscribe(changes, r.Header.Get("Last-Event-ID"), tickers)
+		defer subscription.Close()
+
+		flusher := http.NewResponseController(w)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if expired {
+			// the changes after the id are lost, the client has to load the stocks again
+			fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		}
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			case change, open := <-subscription.Changes():
+				// the hub is closed on shutdown, the client reconnects to the next process
+				if !open {
+					if err := subscription.Err(); !errors.Is(err, watch.ErrClosed) {
+						logrus.WithField("tickers", tickers).Warnln(err)
+					}
+					return
+				}
+
+				data, err := json.Marshal(change.Stock)
+				if err != nil {
+					logrus.Errorln(err)
+					return
+				}
+
+				fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", change.ID, change.Kind, data)
+			}
+
+			if err := flusher.Flush(); err != nil {
+				return
+			}
+		}
+	}).Methods(http.MethodGet)
+}
+
+// subscribe resumes after the last event, it tells when that isn't possible anymore
+func subscribe(changes *watch.Hub, lastEventID string, tickers []string) (*watch.Subscription, bool) {
+	if lastEventID == "" {
+		return changes.Subscribe(tickers, streamBuffer), false
+	}
+
+	subscription, err := changes.Resume(lastEventID, tickers, streamBuffer)
+	if errors.Is(err, watch.ErrExpired) {
+		return changes.Subscribe(tickers, streamBuffer), true
+	}
+
+	return subscription, false
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/watch"
+)
+
+func TestStreamStocks(t *testing.T) {
+	changes := watch.NewHub()
+	router := mux.NewRouter()
+	StreamStocksHandler(router, changes)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	// open reads the events of the stream until the first blank line after an event or reset
+	open := func(t *testing.T, lastEventID string) *bufio.Reader {
+		request, _ := http.NewRequest(http.MethodGet, server.URL+"/stream?symbols=intc", nil)
+		if lastEventID != "" {
+			request.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { response.Body.Close() })
+
+		if response.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("unexpected content type [%s]", response.Header.Get("Content-Type"))
+		}
+
+		return bufio.NewReader(response.Body)
+	}
+
+	next := func(t *testing.T, reader *bufio.Reader) map[string]string {
+		event := map[string]string{}
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" && len(event) > 0 {
+				return event
+			}
+
+			if name, value, ok := strings.Cut(line, ": "); ok && name != "" {
+				event[name] = value
+			}
+		}
+	}
+
+	reader := open(t, "")
+
+	// the subscription is made after the headers are flushed
+	time.Sleep(10 * time.Millisecond)
+	changes.Publish(watch.KindUpdated, model.StockDataInfo{Ticker: "MSFT", Price: 1})
+	changes.Publish(watch.KindUpdated, model.StockDataInfo{Ticker: "INTC", Price: 2})
+	changes.Publish(watch.KindUpdated, model.StockDataInfo{Ticker: "INTC", Price: 3})
+
+	first := next(t, reader)
+	if first["event"] != "updated" || !strings.Contains(first["data"], `"price":2`) {
+		t.Fatalf("unexpected event [%v]", first)
+	}
+
+	t.Run("resumes after the last event id", func(t *testing.T) {
+		event := next(t, open(t, first["id"]))
+
+		if !strings.Contains(event["data"], `"price":3`) {
+			t.Fatalf("unexpected event [%v]", event)
+		}
+	})
+
+	t.Run("resets unknown event ids", func(t *testing.T) {
+		event := next(t, open(t, "1-1"))
+
+		if event["event"] != "reset" {
+			t.Fatalf("expected reset, got [%v]", event)
+		}
+	})
+}
+
+func TestStreamStocksShutdown(t *testing.T) {
+	changes := watch.NewHub()
+	router := mux.NewRouter()
+	StreamStocksHandler(router, changes)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	response, err := http.Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	// the subscription is made after the headers are flushed
+	time.Sleep(10 * time.Millisecond)
+	changes.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, response.Body)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream is still open after the hub was closed")
+	}
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-screener/events"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+)
+
+type webhookRequest struct {
+	URL   string            `json:"url"`
+	Types []model.EventType `json:"types"`
+}
+
+// GetWebhooksHandler lists the webhooks without their secrets
+func GetWebhooksHandler(router *mux.Router, webhooks *events.Webhooks) {
+	router.HandleFunc("/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		result, err := webhooks.List(r.Context())
+
+		if err != nil {
+			logrus.Errorln(err)
+			problem.Write(w, r, err)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+// CreateWebhookHandler subscribes a url to the events, the response holds the only copy of the signing secret
+func CreateWebhookHandler(router *mux.Router, webhooks *events.Webhooks) {
+	router.HandleFunc("/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		var request webhookRequest
+
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(&request); err != nil {
+			problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidRequest, err))
+			return
+		}
+
+		created, err := webhooks.Create(r.Context(), events.WebhookRequest{URL: request.URL, Types: request.Types}, actor(r))
+
+		if err != nil {
+			handleWebhookError(w, r, err, problem.CodeWebhookNotFound, "webhook not found")
+			return
+		}
+
+		logrus.WithField("webhook", created.Webhook.ID).WithField("url", created.Webhook.URL).Infoln("Webhook created")
+
+		stockHttp.HandleJSONResponse(created, w, http.StatusCreated)
+	}).Methods(http.MethodPost)
+}
+
+// DeleteWebhookHandler unsubscribes the webhook
+func DeleteWebhookHandler(router *mux.Router, webhooks *events.Webhooks) {
+	router.HandleFunc("/webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		if err := webhooks.Delete(r.Context(), id); err != nil {
+			handleWebhookError(w, r, err, problem.CodeWebhookNotFound, "webhook not found")
+			return
+		}
+
+		logrus.WithField("webhook", id).Infoln("Webhook deleted")
+
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodDelete)
+}
+
+// GetDeadLettersHandler lists the deliveries given up after the last attempt
+func GetDeadLettersHandler(router *mux.Router, webhooks *events.Webhooks) {
+	router.HandleFunc("/webhooks/dead-letters", func(w http.ResponseWriter, r *http.Request) {
+		result, err := webhooks.DeadLetters(r.Context())
+
+		if err != nil {
+			logrus.Errorln(err)
+			problem.Write(w, r, err)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+// RedeliverHandler schedules the dead letter again
+func RedeliverHandler(router *mux.Router, webhooks *events.Webhooks) {
+	router.HandleFunc("/webhooks/dead-letters/{id}/redeliver", func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		if err := webhooks.Redeliver(r.Context(), id); err != nil {
+			handleWebhookError(w, r, err, problem.CodeDeliveryNotFound, "dead letter not found")
+			return
+		}
+
+		logrus.WithField("delivery", id).Infoln("Dead letter scheduled for redelivery")
+
+		w.WriteHeader(http.StatusAccepted)
+	}).Methods(http.MethodPost)
+}
+
+func handleWebhookError(w http.ResponseWriter, r *http.Request, err error, notFoundCode, notFoundDetail string) {
+	switch {
+	case errors.Is(err, events.ErrInvalidWebhook):
+		problem.Write(w, r, problem.Wrap(http.StatusBadRequest, problem.CodeInvalidWebhook, err))
+	case errors.Is(err, mongo.ErrNoDocuments):
+		problem.Write(w, r, problem.New(http.StatusNotFound, notFoundCode, notFoundDetail))
+	default:
+		logrus.Errorln(err)
+		problem.Write(w, r, err)
+	}
+}
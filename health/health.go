@@ -0,0 +1,92 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDraining = "draining"
+)
+
+//Check reports an unavailable dependency with an error
+type Check func(ctx context.Context) error
+
+//Report is the outcome of the readiness checks
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+//Ready returns if the service can accept traffic
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+//Checker runs the readiness checks of the dependencies
+type Checker struct {
+	timeout  time.Duration
+	names    []string
+	checks   map[string]Check
+	draining int32
+}
+
+//New creates a checker, each check has to finish within the timeout
+func New(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		checks:  make(map[string]Check),
+	}
+}
+
+//Add registers a named dependency check
+func (c *Checker) Add(name string, check Check) {
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+//Drain makes the service unready, so no new traffic is routed to it during shutdown
+func (c *Checker) Drain() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+//Check runs every check concurrently
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]string, len(c.names))}
+
+	if atomic.LoadInt32(&c.draining) == 1 {
+		report.Status = StatusDraining
+		return report
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]error, len(c.names))
+	var wg sync.WaitGroup
+	for i, name := range c.names {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = check(ctx)
+		}(i, c.checks[name])
+	}
+	wg.Wait()
+
+	for i, name := range c.names {
+		if results[i] != nil {
+			report.Status = StatusFailing
+			report.Checks[name] = results[i].Error()
+			continue
+		}
+		report.Checks[name] = StatusOK
+	}
+
+	return report
+}
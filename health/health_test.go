@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	t.Run("reports failing checks by name", func(t *testing.T) {
+		checker := New(time.Second)
+		checker.Add("mongo", func(ctx context.Context) error { return nil })
+		checker.Add("provider", func(ctx context.Context) error { return errors.New("connection refused") })
+
+		report := checker.Check(context.Background())
+
+		if report.Ready() {
+			t.Fatal("expected not ready")
+		}
+
+		if report.Checks["mongo"] != StatusOK || report.Checks["provider"] != "connection refused" {
+			t.Fatalf("unexpected checks [%v]", report.Checks)
+		}
+	})
+
+	t.Run("cancels checks after the timeout", func(t *testing.T) {
+		checker := New(10 * time.Millisecond)
+		checker.Add("mongo", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		report := checker.Check(context.Background())
+
+		if report.Ready() {
+			t.Fatal("expected not ready")
+		}
+	})
+
+	t.Run("is not ready while draining", func(t *testing.T) {
+		checker := New(time.Second)
+		checker.Add("mongo", func(ctx context.Context) error { return nil })
+		checker.Drain()
+
+		report := checker.Check(context.Background())
+
+		if report.Status != StatusDraining {
+			t.Fatalf("expected draining, got [%s]", report.Status)
+		}
+	})
+}
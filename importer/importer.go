@@ -0,0 +1,235 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nagymarci/stock-screener/model"
+)
+
+//ErrEmpty is returned when the upload has no rows
+var ErrEmpty = errors.New("empty import")
+
+//Row is a valid line of the import
+type Row struct {
+	//Number is the position of the row in the file, the header is row 1
+	Number int
+	Stock  model.StockDataInfo
+	//Fields are the stored fields with a value in the row
+	Fields []model.Field
+}
+
+//RowError describes why a row of the import was rejected
+type RowError struct {
+	Row     int    `json:"row"`
+	Ticker  string `json:"ticker,omitempty"`
+	Message string `json:"message"`
+}
+
+//Report summarizes the outcome of an import
+type Report struct {
+	DryRun   bool       `json:"dryRun"`
+	Rows     int        `json:"rows"`
+	Imported int        `json:"imported"`
+	Errors   []RowError `json:"errors"`
+}
+
+//Import is the parsed content of an upload
+type Import struct {
+	//TickersOnly is set when the upload is a plain watchlist without fundamentals
+	TickersOnly bool
+	Rows        []Row
+	Errors      []RowError
+}
+
+//Parse reads a csv watchlist or a csv of stock data, rows failing validation are collected as errors
+func Parse(r io.Reader) (Import, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return Import{}, err
+	}
+
+	if len(records) == 0 {
+		return Import{}, ErrEmpty
+	}
+
+	h, err := parseHeader(records[0])
+	if err != nil {
+		return Import{}, err
+	}
+
+	result := Import{TickersOnly: h.tickersOnly()}
+
+	first := 1
+	if !h.present {
+		first = 0
+	}
+
+	seen := map[string]int{}
+
+	for i := first; i < len(records); i++ {
+		number := i + 1
+		if isBlank(records[i]) {
+			continue
+		}
+
+		stock, fields, err := h.parseRecord(records[i])
+		if err == nil {
+			if previous, ok := seen[stock.Ticker]; ok {
+				err = fmt.Errorf("duplicate of row %d", previous)
+			}
+		}
+
+		if err != nil {
+			result.Errors = append(result.Errors, RowError{Row: number, Ticker: stock.Ticker, Message: err.Error()})
+			continue
+		}
+
+		seen[stock.Ticker] = number
+		result.Rows = append(result.Rows, Row{Number: number, Stock: stock, Fields: fields})
+	}
+
+	return result, nil
+}
+
+type header struct {
+	//present is false for a single column ticker list without header
+	present bool
+	//ticker is the index of the ticker column
+	ticker int
+	//columns holds the settable field of each column, derived fields are skipped as nil
+	columns []*model.Field
+}
+
+// listHeaders are the usual headers of a single column list, only ticker is accepted so the list isn't read with its header
+var listHeaders = map[string]bool{"symbol": true, "symbols": true, "tickers": true, "stock": true, "stocks": true, "name": true}
+
+// lookupColumn returns the field of the column header, spreadsheets often capitalise them so the case is ignored
+func lookupColumn(name string) (model.Field, bool) {
+	for _, f := range model.Fields() {
+		if strings.EqualFold(f.Name, strings.TrimSpace(name)) {
+			return f, true
+		}
+	}
+
+	return model.Field{}, false
+}
+
+func parseHeader(record []string) (header, error) {
+	if len(record) == 1 && !strings.EqualFold(strings.TrimSpace(record[0]), "ticker") {
+		name := strings.TrimSpace(record[0])
+		if _, ok := lookupColumn(name); ok || listHeaders[strings.ToLower(name)] {
+			return header{}, fmt.Errorf("unknown column [%s], the ticker list needs a ticker header or none", record[0])
+		}
+
+		return header{columns: make([]*model.Field, 1)}, nil
+	}
+
+	h := header{present: true, ticker: -1, columns: make([]*model.Field, len(record))}
+
+	for i, name := range record {
+		f, ok := lookupColumn(name)
+		if !ok {
+			return h, fmt.Errorf("unknown column [%s]", name)
+		}
+
+		if f.Name == "ticker" {
+			h.ticker = i
+			continue
+		}
+
+		if f.Set != nil {
+			h.columns[i] = &f
+		}
+	}
+
+	if h.ticker < 0 {
+		return h, errors.New("ticker column is missing")
+	}
+
+	return h, nil
+}
+
+func (h header) tickersOnly() bool {
+	for _, column := range h.columns {
+		if column != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h header) parseRecord(record []string) (model.StockDataInfo, []model.Field, error) {
+	var stock model.StockDataInfo
+	var fields []model.Field
+
+	if len(record) != len(h.columns) {
+		return stock, nil, fmt.Errorf("expected %d columns, got %d", len(h.columns), len(record))
+	}
+
+	stock.Ticker = strings.TrimSpace(record[h.ticker])
+	if stock.Ticker == "" {
+		return stock, nil, errors.New("ticker is empty")
+	}
+
+	symbol, err := model.ParseSymbol(stock.Ticker)
+	if err != nil {
+		return stock, nil, err
+	}
+	stock.Ticker = symbol.String()
+
+	for i, column := range h.columns {
+		raw := strings.TrimSpace(record[i])
+		if column == nil || raw == "" {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return stock, nil, fmt.Errorf("%s: [%s] is not a number", column.Name, raw)
+		}
+
+		column.Set(&stock, value)
+		fields = append(fields, *column)
+	}
+
+	return stock, fields, validate(stock)
+}
+
+func validate(stock model.StockDataInfo) error {
+	switch {
+	case stock.Price < 0:
+		return errors.New("price must not be negative")
+	case stock.Dividend < 0:
+		return errors.New("dividend must not be negative")
+	case stock.PeRatio5yr.Avg < 0 || stock.PeRatio5yr.Min < 0:
+		return errors.New("peRatio5yr must not be negative")
+	case stock.DividendYield5yr.Avg < 0 || stock.DividendYield5yr.Max < 0:
+		return errors.New("dividendYield5yr must not be negative")
+	case stock.PeRatio5yr.Avg != 0 && stock.PeRatio5yr.Min > stock.PeRatio5yr.Avg:
+		return errors.New("peRatio5yr.min is greater than peRatio5yr.avg")
+	case stock.DividendYield5yr.Max != 0 && stock.DividendYield5yr.Avg > stock.DividendYield5yr.Max:
+		return errors.New("dividendYield5yr.avg is greater than dividendYield5yr.max")
+	}
+
+	return nil
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
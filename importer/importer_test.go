@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("parses ticker list without header", func(t *testing.T) {
+		result, err := Parse(strings.NewReader("INTC\nAAPL\n\nMSFT\n"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !result.TickersOnly || len(result.Rows) != 3 || len(result.Errors) != 0 {
+			t.Fatalf("unexpected result %+v", result)
+		}
+	})
+	t.Run("parses stock data and reports invalid rows", func(t *testing.T) {
+		data := "price,ticker,peRatio5yr.avg,peRatio5yr.min,currentPe\n" +
+			"49.28,INTC,14.89,8.79,9.07\n" +
+			"abc,AAPL,30,20,1\n" +
+			"100,MSFT,20,25,1\n" +
+			"100,,20,15,1\n" +
+			"50,INTC,,,\n"
+
+		result, err := Parse(strings.NewReader(data))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.TickersOnly {
+			t.Fatalf("expected stock data import")
+		}
+
+		if len(result.Rows) != 1 || result.Rows[0].Stock.PeRatio5yr.Min != 8.79 || len(result.Rows[0].Fields) != 3 {
+			t.Fatalf("unexpected rows %+v", result.Rows)
+		}
+
+		rows := []int{}
+		for _, e := range result.Errors {
+			rows = append(rows, e.Row)
+		}
+
+		if len(rows) != 4 || rows[0] != 3 || rows[3] != 6 {
+			t.Fatalf("unexpected errors %+v", result.Errors)
+		}
+	})
+	t.Run("normalises tickers and reports duplicates of the canonical form", func(t *testing.T) {
+		result, err := Parse(strings.NewReader("intc\nbrk-b\nINTC.US\nbad ticker\n"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Rows) != 2 || result.Rows[0].Stock.Ticker != "INTC" || result.Rows[1].Stock.Ticker != "BRK.B" {
+			t.Fatalf("unexpected rows %+v", result.Rows)
+		}
+
+		if len(result.Errors) != 2 {
+			t.Fatalf("unexpected errors %+v", result.Errors)
+		}
+	})
+	t.Run("ignores the case of the headers", func(t *testing.T) {
+		result, err := Parse(strings.NewReader("Ticker,Price,PERatio5yr.Avg\nINTC,49.28,14.89\n"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Rows) != 1 || result.Rows[0].Stock.Price != 49.28 || result.Rows[0].Stock.PeRatio5yr.Avg != 14.89 {
+			t.Fatalf("unexpected rows %+v", result.Rows)
+		}
+	})
+	t.Run("rejects unknown column", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("ticker,name\nINTC,Intel\n"))
+
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+	})
+	t.Run("rejects ticker list with other header", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("Symbol\nINTC\n"))
+
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+	})
+	t.Run("rejects empty upload", func(t *testing.T) {
+		_, err := Parse(strings.NewReader(""))
+
+		if err != ErrEmpty {
+			t.Fatalf("expected ErrEmpty, got [%v]", err)
+		}
+	})
+}
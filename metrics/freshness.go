@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+type oldestUpdates interface {
+	OldestUpdates(ctx context.Context) (map[string]time.Time, error)
+}
+
+type freshnessCollector struct {
+	source oldestUpdates
+	age    *prometheus.Desc
+}
+
+//NewFreshnessCollector creates a collector reporting the age of the oldest data per field group at scrape time
+func NewFreshnessCollector(source oldestUpdates) prometheus.Collector {
+	return &freshnessCollector{
+		source: source,
+		age: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "oldest_data_age_seconds"),
+			"Age of the least recently updated stock data per field group.",
+			[]string{"group"}, nil),
+	}
+}
+
+func (fc *freshnessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- fc.age
+}
+
+func (fc *freshnessCollector) Collect(ch chan<- prometheus.Metric) {
+	oldest, err := fc.source.OldestUpdates(context.Background())
+
+	if err != nil {
+		logrus.WithField("component", "metrics").Warnln(err)
+		return
+	}
+
+	now := time.Now()
+	for group, updated := range oldest {
+		ch <- prometheus.MustNewConstMetric(fc.age, prometheus.GaugeValue, now.Sub(updated).Seconds(), group)
+	}
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/urfave/negroni"
+)
+
+const namespace = "stock_screener"
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of handled HTTP requests.",
+	}, []string{"route", "method", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	providerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "provider_request_duration_seconds",
+		Help:      "Latency of the stock data provider calls.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"status"})
+
+	providerErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_errors_total",
+		Help:      "Number of failed stock data provider calls.",
+	}, []string{"status"})
+
+	updaterDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "updater_run_duration_seconds",
+		Help:      "Duration of the updater runs.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300},
+	})
+
+	updaterStocks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "updater_stocks_total",
+		Help:      "Number of stocks processed by the updater by result.",
+	}, []string{"result"})
+
+	expiredStocks = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "updater_expired_stocks",
+		Help:      "Number of stocks with expired data at the start of the last updater run.",
+	})
+)
+
+//Results of the updater for one stock
+const (
+	ResultUpdated     = "updated"
+	ResultFailed      = "failed"
+	ResultQuarantined = "quarantined"
+	ResultSkipped     = "skipped"
+)
+
+//Middleware records the count and latency of the requests by route template, it must be used on the mux router
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		rw, ok := w.(negroni.ResponseWriter)
+		if !ok {
+			rw = negroni.NewResponseWriter(w)
+		}
+
+		next.ServeHTTP(rw, r)
+
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		status := rw.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		requests.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+		requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+//ObserveProviderCall records a provider call, status is the HTTP status or "error" when there was no response
+func ObserveProviderCall(status string, duration time.Duration, failed bool) {
+	providerDuration.WithLabelValues(status).Observe(duration.Seconds())
+
+	if failed {
+		providerErrors.WithLabelValues(status).Inc()
+	}
+}
+
+//ObserveUpdaterRun records the duration of an updater run and the number of stocks it found expired
+func ObserveUpdaterRun(duration time.Duration, expired int) {
+	updaterDuration.Observe(duration.Seconds())
+	expiredStocks.Set(float64(expired))
+}
+
+//CountUpdatedStock counts a stock processed by the updater with the given result
+func CountUpdatedStock(result string) {
+	updaterStocks.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Run("counts requests by route template and status", func(t *testing.T) {
+		router := mux.NewRouter()
+		router.Use(Middleware)
+		router.HandleFunc("/stocks/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		for _, symbol := range []string{"INTC", "AAPL"} {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stocks/"+symbol, nil))
+		}
+
+		count := testutil.ToFloat64(requests.WithLabelValues("/stocks/{symbol}", http.MethodGet, "404"))
+
+		if count != 2 {
+			t.Fatalf("expected 2 requests, got [%v]", count)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	ScopeReadStocks  = "read:stocks"
+	ScopeWriteStocks = "write:stocks"
+	ScopeAdmin       = "admin"
+)
+
+//APIKey is an issued service key, only the hash of the secret is stored
+type APIKey struct {
+	ID        string    `json:"id" bson:"_id"`
+	Name      string    `json:"name" bson:"name"`
+	Hash      string    `json:"-" bson:"hash"`
+	Scopes    []string  `json:"scopes" bson:"scopes"`
+	Created   time.Time `json:"created" bson:"created"`
+	CreatedBy string    `json:"createdBy" bson:"createdBy"`
+	Expires   time.Time `json:"expires,omitempty" bson:"expires,omitempty"`
+	LastUsed  time.Time `json:"lastUsed,omitempty" bson:"lastUsed,omitempty"`
+	Revoked   time.Time `json:"revoked,omitempty" bson:"revoked,omitempty"`
+	// the key this one replaced on rotation
+	RotatedFrom string `json:"rotatedFrom,omitempty" bson:"rotatedFrom,omitempty"`
+}
+
+//Active returns if the key can be used at the given time
+func (k APIKey) Active(now time.Time) bool {
+	return k.Revoked.IsZero() && (k.Expires.IsZero() || now.Before(k.Expires))
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+//Causes of the stock writes
+const (
+	CauseRegistration = "registration"
+	CauseUpdater      = "updater"
+	CauseImport       = "import"
+	CauseApproval     = "approval"
+	CauseOverride     = "override"
+)
+
+//Cause tells why a stock was written, RunID identifies the updater run and Actor the caller of the manual writes
+type Cause struct {
+	Kind  string `json:"kind" bson:"kind"`
+	RunID string `json:"runId,omitempty" bson:"runId,omitempty"`
+	Actor string `json:"actor,omitempty" bson:"actor,omitempty"`
+}
+
+//StockChange is an entry of the audit trail of a stock, one changed value of a write
+type StockChange struct {
+	Ticker      string `json:"ticker" bson:"ticker"`
+	FieldChange `bson:",inline"`
+	Time        time.Time `json:"time" bson:"time"`
+	Cause       Cause     `json:"cause" bson:"cause"`
+}
@@ -0,0 +1,26 @@
+package model
+
+//FieldChange is the old and new value of a stored field
+type FieldChange struct {
+	Field string  `json:"field" bson:"field"`
+	Old   float64 `json:"old" bson:"old"`
+	New   float64 `json:"new" bson:"new"`
+}
+
+//Diff returns the stored values that differ between the stocks, in display order
+func Diff(old, new StockDataInfo) []FieldChange {
+	var result []FieldChange
+
+	for _, f := range fields {
+		if f.Set == nil {
+			continue
+		}
+
+		o, n := f.Value(old).(float64), f.Value(new).(float64)
+		if o != n {
+			result = append(result, FieldChange{Field: f.Name, Old: o, New: n})
+		}
+	}
+
+	return result
+}
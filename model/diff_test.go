@@ -0,0 +1,26 @@
+package model
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	old := StockDataInfo{Ticker: "INTC", Price: 49.28, Eps: 5.43}
+	old.PeRatio5yr.Min = 8.79
+
+	new := old
+	new.Price = 50
+	new.PeRatio5yr.Min = 9.1
+	new.Source = SourceProvider
+
+	changes := Diff(old, new)
+
+	expected := []FieldChange{{Field: "price", Old: 49.28, New: 50}, {Field: "peRatio5yr.min", Old: 8.79, New: 9.1}}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected [%v], got [%v]", expected, changes)
+	}
+
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Fatalf("expected [%v], got [%v]", expected, changes)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package model
+
+import "time"
+
+//EventType names the change an event reports
+type EventType string
+
+const (
+	EventStockRegistered        EventType = "StockRegistered"
+	EventStockDeleted           EventType = "StockDeleted"
+	EventPriceUpdated           EventType = "PriceUpdated"
+	EventPeHistoryUpdated       EventType = "PeHistoryUpdated"
+	EventDividendHistoryUpdated EventType = "DividendHistoryUpdated"
+	EventUpdateFailed           EventType = "UpdateFailed"
+)
+
+//EventTypes lists every event type in the order they are documented
+var EventTypes = []EventType{
+	EventStockRegistered,
+	EventStockDeleted,
+	EventPriceUpdated,
+	EventPeHistoryUpdated,
+	EventDividendHistoryUpdated,
+	EventUpdateFailed,
+}
+
+//Valid returns if the type is one of EventTypes
+func (t EventType) Valid() bool {
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+//Event is a change of a stock published to the webhooks, Stock is missing for deleted stocks and failed updates
+type Event struct {
+	ID     string         `json:"id" bson:"_id"`
+	Type   EventType      `json:"type" bson:"type"`
+	Ticker string         `json:"ticker" bson:"ticker"`
+	Time   time.Time      `json:"time" bson:"time"`
+	Stock  *StockDataInfo `json:"stock,omitempty" bson:"stock,omitempty"`
+	Error  string         `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+//Webhook is a subscription of an external system to the events, no types means every type
+type Webhook struct {
+	ID        string      `json:"id" bson:"_id"`
+	URL       string      `json:"url" bson:"url"`
+	Types     []EventType `json:"types" bson:"types"`
+	Secret    string      `json:"-" bson:"secret"`
+	Created   time.Time   `json:"created" bson:"created"`
+	CreatedBy string      `json:"createdBy" bson:"createdBy"`
+}
+
+//Accepts returns if the events of the type are sent to the webhook
+func (w Webhook) Accepts(t EventType) bool {
+	if len(w.Types) == 0 {
+		return true
+	}
+
+	for _, accepted := range w.Types {
+		if accepted == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+//Delivery statuses, delivered ones are removed
+const (
+	DeliveryPending = "pending"
+	DeliveryDead    = "dead"
+)
+
+//Delivery is an event waiting to be sent to a webhook, or given up after the last attempt
+type Delivery struct {
+	ID          string    `json:"id" bson:"_id"`
+	Webhook     string    `json:"webhook" bson:"webhook"`
+	Event       Event     `json:"event" bson:"event"`
+	Status      string    `json:"status" bson:"status"`
+	Attempts    int       `json:"attempts" bson:"attempts"`
+	NextAttempt time.Time `json:"nextAttempt,omitempty" bson:"nextAttempt"`
+	LastError   string    `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	Created     time.Time `json:"created" bson:"created"`
+}
@@ -0,0 +1,144 @@
+package model
+
+import "strings"
+
+//Field describes an attribute of a stock that can be selected, sorted or exported
+type Field struct {
+	//Name is the dot separated path of the field in the API representation
+	Name string
+	//Path is the location of the field in the stored document, empty for derived fields
+	Path string
+	//Requires lists the stored paths needed to compute the field
+	Requires []string
+	//Value extracts the field from the stock
+	Value func(StockDataInfo) interface{}
+	//Set stores a numeric value in the field, nil for the ticker and derived fields
+	Set func(*StockDataInfo, float64)
+}
+
+//Derived tells if the field is computed instead of stored
+func (f Field) Derived() bool {
+	return f.Path == ""
+}
+
+//Numeric tells if the field holds a number
+func (f Field) Numeric() bool {
+	_, ok := f.Value(StockDataInfo{}).(float64)
+	return ok
+}
+
+//Group returns the name of the field group sharing the update schedule, empty for the price, eps and dividend group
+func (f Field) Group() string {
+	if i := strings.Index(f.Name, "."); i >= 0 {
+		return f.Name[:i]
+	}
+
+	return ""
+}
+
+//NextUpdatePath returns the stored path of the next update time of the field's group
+func (f Field) NextUpdatePath() string {
+	if group := f.Group(); group != "" {
+		return group + ".nextUpdate"
+	}
+
+	return "nextUpdate"
+}
+
+var fields = []Field{
+	{Name: "ticker", Path: "ticker", Value: func(s StockDataInfo) interface{} { return s.Ticker }},
+	{Name: "price", Path: "price", Value: func(s StockDataInfo) interface{} { return s.Price },
+		Set: func(s *StockDataInfo, v float64) { s.Price = v }},
+	{Name: "eps", Path: "eps", Value: func(s StockDataInfo) interface{} { return s.Eps },
+		Set: func(s *StockDataInfo, v float64) { s.Eps = v }},
+	{Name: "dividend", Path: "dividend", Value: func(s StockDataInfo) interface{} { return s.Dividend },
+		Set: func(s *StockDataInfo, v float64) { s.Dividend = v }},
+	{Name: "peRatio5yr.avg", Path: "peRatio5yr.avg", Value: func(s StockDataInfo) interface{} { return s.PeRatio5yr.Avg },
+		Set: func(s *StockDataInfo, v float64) { s.PeRatio5yr.Avg = v }},
+	{Name: "peRatio5yr.min", Path: "peRatio5yr.min", Value: func(s StockDataInfo) interface{} { return s.PeRatio5yr.Min },
+		Set: func(s *StockDataInfo, v float64) { s.PeRatio5yr.Min = v }},
+	{Name: "dividendYield5yr.avg", Path: "dividendYield5yr.avg", Value: func(s StockDataInfo) interface{} { return s.DividendYield5yr.Avg },
+		Set: func(s *StockDataInfo, v float64) { s.DividendYield5yr.Avg = v }},
+	{Name: "dividendYield5yr.max", Path: "dividendYield5yr.max", Value: func(s StockDataInfo) interface{} { return s.DividendYield5yr.Max },
+		Set: func(s *StockDataInfo, v float64) { s.DividendYield5yr.Max = v }},
+	{Name: "lastUpdated", Path: "lastUpdated", Value: func(s StockDataInfo) interface{} { return s.LastUpdated }},
+	{Name: "source", Path: "source", Value: func(s StockDataInfo) interface{} { return s.Source }},
+	{Name: "peRatio5yr.lastUpdated", Path: "peRatio5yr.lastUpdated", Value: func(s StockDataInfo) interface{} { return s.PeRatio5yr.LastUpdated }},
+	{Name: "peRatio5yr.source", Path: "peRatio5yr.source", Value: func(s StockDataInfo) interface{} { return s.PeRatio5yr.Source }},
+	{Name: "dividendYield5yr.lastUpdated", Path: "dividendYield5yr.lastUpdated", Value: func(s StockDataInfo) interface{} { return s.DividendYield5yr.LastUpdated }},
+	{Name: "dividendYield5yr.source", Path: "dividendYield5yr.source", Value: func(s StockDataInfo) interface{} { return s.DividendYield5yr.Source }},
+	{Name: "stale", Requires: []string{"nextUpdate", "peRatio5yr.nextUpdate", "dividendYield5yr.nextUpdate"}, Value: func(s StockDataInfo) interface{} { return s.Stale }},
+	{Name: "currentPe", Requires: []string{"price", "eps"}, Value: func(s StockDataInfo) interface{} { return s.CurrentPe() }},
+	{Name: "currentDividendYield", Requires: []string{"price", "dividend"}, Value: func(s StockDataInfo) interface{} { return s.CurrentDividendYield() }},
+}
+
+//Fields returns every known field in display order
+func Fields() []Field {
+	result := make([]Field, len(fields))
+	copy(result, fields)
+	return result
+}
+
+//LookupField returns the field with the given name
+func LookupField(name string) (Field, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+
+	return Field{}, false
+}
+
+//ExpandFields resolves the requested names to fields, a group name like peRatio5yr selects all of its fields
+func ExpandFields(names []string) ([]Field, []string) {
+	var result []Field
+	var unknown []string
+
+	for _, name := range names {
+		found := false
+		for _, f := range fields {
+			if f.Name == name || strings.HasPrefix(f.Name, name+".") {
+				result = appendField(result, f)
+				found = true
+			}
+		}
+
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+
+	return result, unknown
+}
+
+func appendField(list []Field, f Field) []Field {
+	for _, existing := range list {
+		if existing.Name == f.Name {
+			return list
+		}
+	}
+
+	return append(list, f)
+}
+
+//Project returns the selected fields of the stock keyed the same way as the json representation
+func Project(stock StockDataInfo, selected []Field) map[string]interface{} {
+	result := map[string]interface{}{}
+
+	for _, f := range selected {
+		parts := strings.Split(f.Name, ".")
+		target := result
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := target[part].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				target[part] = next
+			}
+			target = next
+		}
+		target[parts[len(parts)-1]] = f.Value(stock)
+	}
+
+	return result
+}
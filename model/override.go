@@ -0,0 +1,115 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+//ErrInvalidOverride is returned for overrides of unknown or derived fields, or without a reason
+var ErrInvalidOverride = errors.New("invalid override")
+
+//Override pins the value of a stored number, the updater keeps it until the override expires
+type Override struct {
+	Field     string    `json:"field" bson:"field"`
+	Value     float64   `json:"value" bson:"value"`
+	Reason    string    `json:"reason" bson:"reason"`
+	Expires   time.Time `json:"expires,omitempty" bson:"expires,omitempty"`
+	Created   time.Time `json:"created" bson:"created"`
+	CreatedBy string    `json:"createdBy" bson:"createdBy"`
+}
+
+//MarshalJSON leaves out the expiry of the overrides kept until removed, a zero time isn't omitted by the tag
+func (o Override) MarshalJSON() ([]byte, error) {
+	type plain Override
+
+	var expires *time.Time
+	if !o.Expires.IsZero() {
+		expires = &o.Expires
+	}
+
+	return json.Marshal(struct {
+		plain
+		Expires *time.Time `json:"expires,omitempty"`
+	}{plain(o), expires})
+}
+
+//Active returns if the override pins the value at the given time
+func (o Override) Active(now time.Time) bool {
+	return o.Expires.IsZero() || now.Before(o.Expires)
+}
+
+//ValidateOverrides checks that every override pins a different stored number with a reason and doesn't expire before now
+func ValidateOverrides(overrides []Override, now time.Time) error {
+	seen := map[string]bool{}
+
+	for _, o := range overrides {
+		if f, ok := LookupField(o.Field); !ok || f.Set == nil {
+			return fmt.Errorf("%w: [%s] is not a stored number", ErrInvalidOverride, o.Field)
+		}
+
+		if seen[o.Field] {
+			return fmt.Errorf("%w: [%s] is overridden more than once", ErrInvalidOverride, o.Field)
+		}
+		seen[o.Field] = true
+
+		if o.Reason == "" {
+			return fmt.Errorf("%w: the override of [%s] needs a reason", ErrInvalidOverride, o.Field)
+		}
+
+		if !o.Active(now) {
+			return fmt.Errorf("%w: the override of [%s] is already expired", ErrInvalidOverride, o.Field)
+		}
+	}
+
+	return nil
+}
+
+//ApplyOverrides sets the values pinned by the active overrides of the stock
+func (s *StockDataInfo) ApplyOverrides(now time.Time) {
+	for _, o := range s.Overrides {
+		if f, ok := LookupField(o.Field); ok && f.Set != nil && o.Active(now) {
+			f.Set(s, o.Value)
+		}
+	}
+}
+
+//PruneOverrides drops the expired overrides, so only the pinned fields are flagged
+func (s *StockDataInfo) PruneOverrides(now time.Time) {
+	var active []Override
+
+	for _, o := range s.Overrides {
+		if o.Active(now) {
+			active = append(active, o)
+		}
+	}
+
+	s.Overrides = active
+}
+
+//ExpireOverrides brings the next update of the groups forward to the expiry of their active overrides,
+//so the pinned values are replaced by the provider once they expire
+func (s *StockDataInfo) ExpireOverrides(overrides []Override, now time.Time) {
+	for _, o := range overrides {
+		f, ok := LookupField(o.Field)
+		if !ok || o.Expires.IsZero() || !o.Active(now) {
+			continue
+		}
+
+		if next := s.nextUpdate(f.Group()); o.Expires.Before(*next) {
+			*next = o.Expires
+		}
+	}
+}
+
+func (s *StockDataInfo) nextUpdate(group string) *time.Time {
+	switch group {
+	case "peRatio5yr":
+		return &s.PeRatio5yr.NextUpdate
+	case "dividendYield5yr":
+		return &s.DividendYield5yr.NextUpdate
+	default:
+		return &s.NextUpdate
+	}
+}
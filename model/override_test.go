@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOverrides(t *testing.T) {
+	now := time.Now()
+
+	t.Run("rejects invalid overrides", func(t *testing.T) {
+		invalid := [][]Override{
+			{{Field: "ticker", Reason: "typo"}},
+			{{Field: "price", Value: 1}},
+			{{Field: "price", Reason: "split"}, {Field: "price", Reason: "split"}},
+			{{Field: "price", Reason: "split", Expires: now.Add(-time.Hour)}},
+		}
+
+		for _, overrides := range invalid {
+			if err := ValidateOverrides(overrides, now); !errors.Is(err, ErrInvalidOverride) {
+				t.Fatalf("expected invalid override for [%+v], got [%v]", overrides, err)
+			}
+		}
+	})
+
+	t.Run("applies active overrides", func(t *testing.T) {
+		stock := StockDataInfo{Price: 50, Overrides: []Override{
+			{Field: "peRatio5yr.min", Value: 8.79, Reason: "negative earnings"},
+			{Field: "price", Value: 1, Reason: "split", Expires: now.Add(-time.Hour)},
+		}}
+
+		stock.ApplyOverrides(now)
+		stock.PruneOverrides(now)
+
+		if stock.PeRatio5yr.Min != 8.79 || stock.Price != 50 || len(stock.Overrides) != 1 {
+			t.Fatalf("unexpected stock [%+v]", stock)
+		}
+	})
+
+	t.Run("schedules the groups at the expiry of their overrides", func(t *testing.T) {
+		stock := StockDataInfo{NextUpdate: now.Add(24 * time.Hour)}
+		stock.PeRatio5yr.NextUpdate = now.Add(24 * time.Hour)
+
+		stock.ExpireOverrides([]Override{
+			{Field: "peRatio5yr.min", Value: 8.79, Reason: "negative earnings", Expires: now.Add(time.Hour)},
+			{Field: "price", Value: 1, Reason: "split"},
+		}, now)
+
+		if !stock.PeRatio5yr.NextUpdate.Equal(now.Add(time.Hour)) || !stock.NextUpdate.Equal(now.Add(24*time.Hour)) {
+			t.Fatalf("unexpected next updates [%v] [%v]", stock.PeRatio5yr.NextUpdate, stock.NextUpdate)
+		}
+	})
+	t.Run("leaves out the missing expiry from the json", func(t *testing.T) {
+		expiring := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+		data, err := json.Marshal([]Override{
+			{Field: "price", Value: 1, Reason: "split"},
+			{Field: "eps", Value: 1, Reason: "restated", Expires: expiring},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded []map[string]interface{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := decoded[0]["expires"]; ok || decoded[1]["expires"] != "2026-10-19T16:00:00Z" || decoded[0]["reason"] != "split" {
+			t.Fatalf("unexpected json %s", data)
+		}
+	})
+}
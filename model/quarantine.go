@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+//Violation describes a failed validation rule
+type Violation struct {
+	Field   string `json:"field" bson:"field"`
+	Message string `json:"message" bson:"message"`
+}
+
+//QuarantineEntry holds a provider update that failed validation and waits for review
+type QuarantineEntry struct {
+	Ticker     string        `json:"ticker" bson:"ticker"`
+	Fields     []string      `json:"fields" bson:"fields"`
+	Previous   StockDataInfo `json:"previous" bson:"previous"`
+	Proposed   StockDataInfo `json:"proposed" bson:"proposed"`
+	Violations []Violation   `json:"violations" bson:"violations"`
+	Created    time.Time     `json:"created" bson:"created"`
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	SettingsSourceAPI  = "api"
+	SettingsSourceFile = "file"
+)
+
+//Duration is a time.Duration written as text in JSON, e.g. "1h30m"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration must be a string like \"1h30m\": %v", err)
+	}
+
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+//Settings are the updater settings that can be changed without restarting the service
+type Settings struct {
+	StockUpdateInterval Duration `json:"stockUpdateInterval" bson:"stockUpdateInterval"`
+	PeUpdateInterval    Duration `json:"peUpdateInterval" bson:"peUpdateInterval"`
+	DivUpdateInterval   Duration `json:"divUpdateInterval" bson:"divUpdateInterval"`
+	StockUpdateJitter   Duration `json:"stockUpdateJitter" bson:"stockUpdateJitter"`
+	HistoryUpdateJitter Duration `json:"historyUpdateJitter" bson:"historyUpdateJitter"`
+	UpdateCron          string   `json:"updateCron" bson:"updateCron"`
+	ProviderURL         string   `json:"providerUrl" bson:"providerUrl"`
+	Concurrency         int      `json:"concurrency" bson:"concurrency"`
+}
+
+//SettingsChange is the audit entry of a settings change
+type SettingsChange struct {
+	Previous Settings  `json:"previous" bson:"previous"`
+	Current  Settings  `json:"current" bson:"current"`
+	Source   string    `json:"source" bson:"source"`
+	Actor    string    `json:"actor" bson:"actor"`
+	Changed  time.Time `json:"changed" bson:"changed"`
+}
@@ -4,16 +4,26 @@ import (
 	"time"
 )
 
+//Sources of the stored values
+const (
+	SourceProvider = "provider"
+	SourceImport   = "import"
+)
+
 type pERatioInfo struct {
-	Avg        float64   `json:"avg" bson:"avg"`
-	Min        float64   `json:"min" bson:"min"`
-	NextUpdate time.Time `json:"-" bson:"nextUpdate"`
+	Avg         float64   `json:"avg" bson:"avg"`
+	Min         float64   `json:"min" bson:"min"`
+	NextUpdate  time.Time `json:"-" bson:"nextUpdate"`
+	LastUpdated time.Time `json:"lastUpdated" bson:"lastUpdated"`
+	Source      string    `json:"source" bson:"source"`
 }
 
 type dividendYieldInfo struct {
-	Avg        float64   `json:"avg" bson:"avg"`
-	Max        float64   `json:"max" bson:"max"`
-	NextUpdate time.Time `json:"-" bson:"nextUpdate"`
+	Avg         float64   `json:"avg" bson:"avg"`
+	Max         float64   `json:"max" bson:"max"`
+	NextUpdate  time.Time `json:"-" bson:"nextUpdate"`
+	LastUpdated time.Time `json:"lastUpdated" bson:"lastUpdated"`
+	Source      string    `json:"source" bson:"source"`
 }
 
 //StockDataInfo holds the information for one stock
@@ -25,6 +35,46 @@ type StockDataInfo struct {
 	PeRatio5yr       pERatioInfo       `json:"peRatio5yr" bson:"peRatio5yr"`
 	DividendYield5yr dividendYieldInfo `json:"dividendYield5yr" bson:"dividendYield5yr"`
 	NextUpdate       time.Time         `json:"-" bson:"nextUpdate"`
+	LastUpdated      time.Time         `json:"lastUpdated" bson:"lastUpdated"`
+	Source           string            `json:"source" bson:"source"`
+	Stale            bool              `json:"stale" bson:"-"`
+	// the fields pinned by admins
+	Overrides []Override `json:"overrides,omitempty" bson:"overrides,omitempty"`
+}
+
+//SetProvenance records the update time and source of every field group
+func (s *StockDataInfo) SetProvenance(updated time.Time, source string) {
+	s.LastUpdated = updated
+	s.Source = source
+	s.PeRatio5yr.LastUpdated = updated
+	s.PeRatio5yr.Source = source
+	s.DividendYield5yr.LastUpdated = updated
+	s.DividendYield5yr.Source = source
+}
+
+//UpdateStale marks the stock stale when any field group missed its scheduled update by more than the threshold
+func (s *StockDataInfo) UpdateStale(now time.Time, threshold time.Duration) {
+	s.Stale = s.NextUpdate.Add(threshold).Before(now) ||
+		s.PeRatio5yr.NextUpdate.Add(threshold).Before(now) ||
+		s.DividendYield5yr.NextUpdate.Add(threshold).Before(now)
+}
+
+//CurrentPe returns the price to earnings ratio calculated from the latest price and eps
+func (s StockDataInfo) CurrentPe() float64 {
+	if s.Eps == 0 {
+		return 0
+	}
+
+	return s.Price / s.Eps
+}
+
+//CurrentDividendYield returns the annual dividend yield in percent, dividend holds the quarterly payout
+func (s StockDataInfo) CurrentDividendYield() float64 {
+	if s.Price == 0 {
+		return 0
+	}
+
+	return s.Dividend * 4 / s.Price * 100
 }
 
 //Stocks represent list of stocks
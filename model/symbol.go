@@ -0,0 +1,219 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//ErrInvalidSymbol is returned for strings that can't be a ticker symbol
+var ErrInvalidSymbol = errors.New("invalid symbol")
+
+//Symbol identifies a listed stock, the MIC is empty for the US listing
+type Symbol struct {
+	Ticker string
+	// share class, e.g. B of BRK.B
+	Class string
+	MIC   string
+}
+
+var (
+	tickerPattern   = regexp.MustCompile(`^[A-Z0-9]{1,10}$`)
+	classPattern    = regexp.MustCompile(`^[A-Z]$`)
+	qualifierFormat = regexp.MustCompile(`^[A-Z]{2,8}$`)
+)
+
+// usVenues are qualifiers of the default listing, they are dropped from the canonical form
+var usVenues = map[string]bool{
+	"US": true, "XNAS": true, "NASDAQ": true, "XNYS": true, "NYSE": true,
+	"ARCX": true, "NYSEARCA": true, "XASE": true, "AMEX": true, "BATS": true,
+}
+
+// exchanges maps the common exchange suffixes and MICs to the MIC
+var exchanges = map[string]string{
+	"LN": "XLON", "LSE": "XLON", "XLON": "XLON",
+	"DE": "XETR", "GY": "XETR", "XETRA": "XETR", "XETR": "XETR",
+	"PA": "XPAR", "FP": "XPAR", "XPAR": "XPAR",
+	"AS": "XAMS", "NA": "XAMS", "XAMS": "XAMS",
+	"BR": "XBRU", "XBRU": "XBRU",
+	"MI": "XMIL", "IM": "XMIL", "XMIL": "XMIL",
+	"MC": "XMAD", "SM": "XMAD", "XMAD": "XMAD",
+	"LS": "XLIS", "XLIS": "XLIS",
+	"SW": "XSWX", "SIX": "XSWX", "XSWX": "XSWX",
+	"VI": "XWBO", "XWBO": "XWBO",
+	"ST": "XSTO", "SS": "XSTO", "XSTO": "XSTO",
+	"CO": "XCSE", "DC": "XCSE", "XCSE": "XCSE",
+	"HE": "XHEL", "FH": "XHEL", "XHEL": "XHEL",
+	"OL": "XOSL", "NO": "XOSL", "XOSL": "XOSL",
+	"TO": "XTSE", "CN": "XTSE", "TSX": "XTSE", "XTSE": "XTSE",
+	"AX": "XASX", "AU": "XASX", "ASX": "XASX", "XASX": "XASX",
+	"HK": "XHKG", "XHKG": "XHKG",
+	"JP": "XTKS", "XTKS": "XTKS",
+}
+
+//ParseSymbol normalises the symbol, exchange qualified forms like AAPL.US, XNAS:AAPL or SAP.DE are accepted.
+//Single letter suffixes are share classes, BRK.B, BRK-B and BRK/B are the same.
+func ParseSymbol(s string) (Symbol, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+
+	if value == "" {
+		return Symbol{}, fmt.Errorf("%w: symbol is empty", ErrInvalidSymbol)
+	}
+
+	result := Symbol{}
+
+	if parts := strings.Split(value, ":"); len(parts) == 2 {
+		rest, mic, err := splitQualified(parts[0], parts[1])
+		if err != nil {
+			return Symbol{}, fmt.Errorf("%w: %v in [%s]", ErrInvalidSymbol, err, s)
+		}
+
+		result.MIC = mic
+		value = rest
+	} else if len(parts) > 2 {
+		return Symbol{}, fmt.Errorf("%w: [%s]", ErrInvalidSymbol, s)
+	}
+
+	// the one letter suffixes of the provider notation, e.g. VOD.L, are exchanges and not share classes
+	if i := strings.LastIndex(value, "."); i > 0 {
+		if mic, known := providerExchanges[value[i+1:]]; known {
+			if result.MIC != "" && result.MIC != mic {
+				return Symbol{}, fmt.Errorf("%w: conflicting exchanges in [%s]", ErrInvalidSymbol, s)
+			}
+
+			result.MIC = mic
+			value = value[:i]
+		}
+	}
+
+	value = strings.NewReplacer("-", ".", "/", ".").Replace(value)
+	parts := strings.Split(value, ".")
+
+	if len(parts) > 1 && qualifierFormat.MatchString(parts[len(parts)-1]) {
+		mic, known := exchange(parts[len(parts)-1])
+		if !known {
+			return Symbol{}, fmt.Errorf("%w: unknown exchange suffix in [%s]", ErrInvalidSymbol, s)
+		}
+
+		if result.MIC != "" && result.MIC != mic {
+			return Symbol{}, fmt.Errorf("%w: conflicting exchanges in [%s]", ErrInvalidSymbol, s)
+		}
+
+		result.MIC = mic
+		parts = parts[:len(parts)-1]
+	}
+
+	switch len(parts) {
+	case 1:
+		result.Ticker = parts[0]
+	case 2:
+		result.Ticker, result.Class = parts[0], parts[1]
+	default:
+		return Symbol{}, fmt.Errorf("%w: [%s]", ErrInvalidSymbol, s)
+	}
+
+	if !tickerPattern.MatchString(result.Ticker) {
+		return Symbol{}, fmt.Errorf("%w: [%s] must be 1-10 letters or digits", ErrInvalidSymbol, s)
+	}
+
+	if result.Class != "" && !classPattern.MatchString(result.Class) {
+		return Symbol{}, fmt.Errorf("%w: share class of [%s] must be a single letter", ErrInvalidSymbol, s)
+	}
+
+	// the canonical form of such a class would be read back as the exchange
+	if _, suffix := providerExchanges[result.Class]; suffix {
+		return Symbol{}, fmt.Errorf("%w: share class of [%s] is ambiguous with an exchange suffix", ErrInvalidSymbol, s)
+	}
+
+	return result, nil
+}
+
+// splitQualified returns the ticker and the MIC of a colon separated symbol.
+// A venue on the left is MIC:TICKER, so NYSE:ST is ST and not a suffix of NYSE.
+// Otherwise it's the canonical TICKER:MIC, which keeps tickers like ST:XSTO that are suffixes themselves.
+func splitQualified(left, right string) (string, string, error) {
+	leftMIC, leftVenue := venue(left)
+	rightMIC, rightKnown := exchange(right)
+
+	switch {
+	case leftVenue && rightKnown && isMIC(left) && isMIC(right):
+		return "", "", errors.New("ambiguous exchanges")
+	case leftVenue && (!rightKnown || !isMIC(right)):
+		return right, leftMIC, nil
+	case rightKnown:
+		return left, rightMIC, nil
+	default:
+		return "", "", errors.New("unknown exchange")
+	}
+}
+
+// venue returns the MIC of a qualifier that can precede the ticker, the two letter suffixes only follow it
+func venue(qualifier string) (string, bool) {
+	mic, known := exchange(qualifier)
+	return mic, known && (usVenues[qualifier] || len(qualifier) > 2)
+}
+
+// usMICs are the MICs of the US venues
+var usMICs = map[string]bool{"XNAS": true, "XNYS": true, "ARCX": true, "XASE": true}
+
+// isMIC tells if the qualifier is a MIC, the form used in the canonical symbols
+func isMIC(qualifier string) bool {
+	return usMICs[qualifier] || exchanges[qualifier] == qualifier
+}
+
+// exchange returns the MIC of the qualifier, the US venues have the empty MIC
+func exchange(qualifier string) (string, bool) {
+	if usVenues[qualifier] {
+		return "", true
+	}
+
+	mic, ok := exchanges[qualifier]
+	return mic, ok
+}
+
+// providerSuffixes are the exchange suffixes of the provider notation by MIC
+var providerSuffixes = map[string]string{
+	"XLON": "L", "XETR": "DE", "XPAR": "PA", "XAMS": "AS", "XBRU": "BR", "XMIL": "MI", "XMAD": "MC",
+	"XLIS": "LS", "XSWX": "SW", "XWBO": "VI", "XSTO": "ST", "XCSE": "CO", "XHEL": "HE", "XOSL": "OL",
+	"XTSE": "TO", "XASX": "AX", "XHKG": "HK", "XTKS": "T",
+}
+
+// providerExchanges maps the exchange suffixes of the provider notation to the MIC
+var providerExchanges = func() map[string]string {
+	result := map[string]string{}
+	for mic, suffix := range providerSuffixes {
+		result[suffix] = mic
+	}
+	return result
+}()
+
+//Provider returns the symbol in the notation of the data provider, e.g. AAPL, BRK-B or SAP.DE
+func (s Symbol) Provider() string {
+	result := s.Ticker
+
+	if s.Class != "" {
+		result += "-" + s.Class
+	}
+
+	if suffix, ok := providerSuffixes[s.MIC]; ok {
+		result += "." + suffix
+	}
+
+	return result
+}
+
+//String returns the canonical form stored as the ticker, e.g. AAPL, BRK.B or SAP:XETR
+func (s Symbol) String() string {
+	result := s.Ticker
+
+	if s.Class != "" {
+		result += "." + s.Class
+	}
+
+	if s.MIC != "" {
+		result += ":" + s.MIC
+	}
+
+	return result
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSymbol(t *testing.T) {
+	t.Run("normalises to the canonical form", func(t *testing.T) {
+		tests := map[string]string{
+			"aapl":      "AAPL",
+			" AAPL ":    "AAPL",
+			"AAPL.US":   "AAPL",
+			"XNAS:AAPL": "AAPL",
+			"aapl:xnas": "AAPL",
+			"brk-b":     "BRK.B",
+			"BRK/B.US":  "BRK.B",
+			"SAP.DE":    "SAP:XETR",
+			"XETR:SAP":  "SAP:XETR",
+			"CO:XCSE":   "CO:XCSE",
+			"DE":        "DE",
+			"NYSE:ST":   "ST",
+			"NYSE:SM":   "SM",
+			"XSTO:ST":   "ST:XSTO",
+			"ST:XSTO":   "ST:XSTO",
+			"SIX:XSTO":  "SIX:XSTO",
+			"VOD.L":     "VOD:XLON",
+			"7203.T":    "7203:XTKS",
+			"BRK.B":     "BRK.B",
+		}
+
+		for input, expected := range tests {
+			symbol, err := ParseSymbol(input)
+
+			if err != nil {
+				t.Fatalf("[%s]: %v", input, err)
+			}
+
+			if symbol.String() != expected {
+				t.Fatalf("[%s]: expected [%s], got [%s]", input, expected, symbol)
+			}
+
+			again, err := ParseSymbol(symbol.String())
+			if err != nil || again != symbol {
+				t.Fatalf("[%s]: canonical form doesn't parse back, got [%v] [%v]", input, again, err)
+			}
+		}
+	})
+
+	t.Run("rejects garbage", func(t *testing.T) {
+		for _, input := range []string{"", "AAPL; DROP", "../etc/passwd", "AAPL.XYZQ", "TOOLONGTICKER1", "A.B.C", "XLON:XSTO", "BRK-L", "VOD.L:XTKS"} {
+			if _, err := ParseSymbol(input); !errors.Is(err, ErrInvalidSymbol) {
+				t.Fatalf("[%s]: expected invalid symbol, got [%v]", input, err)
+			}
+		}
+	})
+
+	t.Run("provider notation", func(t *testing.T) {
+		tests := map[string]string{
+			"AAPL":     "AAPL",
+			"BRK.B":    "BRK-B",
+			"SAP:XETR": "SAP.DE",
+			"VOD:XLON": "VOD.L",
+			"VOD.L":    "VOD.L",
+			"7203.T":   "7203.T",
+		}
+
+		for input, expected := range tests {
+			symbol, err := ParseSymbol(input)
+
+			if err != nil {
+				t.Fatalf("[%s]: %v", input, err)
+			}
+
+			if symbol.Provider() != expected {
+				t.Fatalf("[%s]: expected [%s], got [%s]", input, expected, symbol.Provider())
+			}
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+func init() {
+	// imports are parsed by the importer, rows with a different number of columns are reported per row
+	openapi3filter.RegisterBodyDecoder("text/csv", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", openapi3filter.FileBodyDecoder)
+}
+
+//Spec returns the OpenAPI 3 document of the api
+func Spec() []byte {
+	return spec
+}
+
+//SwaggerUI returns the page rendering the document
+func SwaggerUI() []byte {
+	return swaggerUI
+}
+
+//Load parses and validates the document
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, doc.Validate(context.Background())
+}
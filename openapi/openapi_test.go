@@ -0,0 +1,79 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the schema must follow the model, the responses are encoded from it
+	encoded, _ := json.Marshal(model.StockDataInfo{Ticker: "INTC", Source: model.SourceProvider})
+
+	var stock interface{}
+	json.Unmarshal(encoded, &stock)
+
+	if err := doc.Components.Schemas["StockDataInfo"].Value.VisitJSON(stock); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidator(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stock := map[string]interface{}{"ticker": "INTC"}
+
+	router := mux.NewRouter()
+	router.Use(validator.Requests())
+	router.HandleFunc("/v1/stocks", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]interface{}{stock})
+	}).Methods(http.MethodGet)
+
+	t.Run("rejects requests not matching the document", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/stocks?limit=abc", nil))
+
+		var p problem.Problem
+		json.NewDecoder(rec.Body).Decode(&p)
+
+		if rec.Code != http.StatusBadRequest || p.Code != problem.CodeInvalidQuery {
+			t.Fatalf("expected invalid query, got [%d] %+v", rec.Code, p)
+		}
+	})
+	t.Run("reports responses not matching the document", func(t *testing.T) {
+		var reported []error
+		handler := validator.Responses(router, func(r *http.Request, err error) {
+			reported = append(reported, err)
+		})
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/stocks?fields=ticker", nil))
+
+		if len(reported) != 0 {
+			t.Fatalf("unexpected errors %v", reported)
+		}
+
+		stock["tickerSymbol"] = "INTC"
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/stocks", nil))
+
+		if len(reported) != 1 {
+			t.Fatalf("expected the unknown field to be reported, got %v", reported)
+		}
+	})
+}
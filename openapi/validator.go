@@ -0,0 +1,158 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nagymarci/stock-screener/problem"
+)
+
+//Validator checks requests and responses against the document
+type Validator struct {
+	router routers.Router
+}
+
+//NewValidator loads the document and builds the routes to validate against
+func NewValidator() (*Validator, error) {
+	doc, err := Load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Validator{router: router}, nil
+}
+
+//Requests answers the requests that don't match the document with bad request.
+//Routes missing from the document are let through and logged.
+func (v *Validator) Requests() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			input, err := v.input(r)
+
+			if err != nil {
+				logrus.WithField("path", r.URL.Path).Warnf("Request isn't described by the OpenAPI document: %v\n", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// uploads are files to the document, they are checked by the importer as they are read
+			input.Options.ExcludeRequestBody = isFile(r.Header.Get("Content-Type"))
+
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				problem.Write(w, r, problem.New(http.StatusBadRequest, requestCode(err), err.Error()))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+//Responses passes every response that doesn't match the document, or belongs to an undocumented route,
+//to report. It buffers the whole response, it's meant for tests.
+func (v *Validator) Responses(next http.Handler, report func(r *http.Request, err error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		input, err := v.input(r)
+
+		if err != nil {
+			next.ServeHTTP(w, r)
+			report(r, err)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		input.Options.ExcludeRequestBody = true
+		input.Options.IncludeResponseStatus = true
+
+		err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.status,
+			Header:                 rec.Header(),
+			Body:                   io.NopCloser(&rec.body),
+			Options:                input.Options,
+		})
+
+		if err != nil {
+			report(r, err)
+		}
+	})
+}
+
+func (v *Validator) input(r *http.Request) (*openapi3filter.RequestValidationInput, error) {
+	route, params, err := v.router.FindRoute(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: params,
+		Route:      route,
+		Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+	}, nil
+}
+
+func requestCode(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if errors.As(err, &requestErr) && requestErr.Parameter != nil && requestErr.Parameter.In == openapi3.ParameterInQuery {
+		return problem.CodeInvalidQuery
+	}
+
+	return problem.CodeInvalidRequest
+}
+
+func isFile(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	return mediaType == "text/csv" || mediaType == "multipart/form-data"
+}
+
+// recorder keeps a copy of the response while writing it
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *recorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,157 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+//ContentType is the media type of the error responses
+const ContentType = "application/problem+json"
+
+//TypeBase prefixes the code in the type of the problem
+const TypeBase = "urn:stock-screener:problem:"
+
+// codes are part of the api, clients match on them instead of the detail, so they must not change
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeInvalidSymbol        = "invalid_symbol"
+	CodeInvalidQuery         = "invalid_query"
+	CodeInvalidCursor        = "invalid_cursor"
+	CodeInvalidImport        = "invalid_import"
+	CodeUnsupportedFormat    = "unsupported_format"
+	CodeInvalidSettings      = "invalid_settings"
+	CodeInvalidAPIKeyRequest = "invalid_api_key_request"
+	CodeInvalidWebhook       = "invalid_webhook"
+	CodeInvalidOverride      = "invalid_override"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeStockNotFound        = "stock_not_found"
+	CodeQuarantineNotFound   = "quarantine_entry_not_found"
+	CodeAPIKeyNotFound       = "api_key_not_found"
+	CodeWebhookNotFound      = "webhook_not_found"
+	CodeDeliveryNotFound     = "delivery_not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeUnknownSymbol        = "unknown_symbol"
+	CodeRateLimited          = "rate_limited"
+	CodeQuotaExceeded        = "quota_exceeded"
+	CodeProviderUnavailable  = "provider_unavailable"
+	CodeInternal             = "internal_error"
+)
+
+//Problem is the RFC 7807 body of the error responses, Code is the stable identifier of the error
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+}
+
+//Error carries the status and code of the response
+type Error struct {
+	Code   string
+	Detail string
+	status int
+	err    error
+}
+
+//New returns an error answered with the status and code
+func New(status int, code, detail string) error {
+	return &Error{Code: code, Detail: detail, status: status}
+}
+
+//Wrap returns an error answered with the status and code, the detail is the message of err
+func Wrap(status int, code string, err error) error {
+	return &Error{Code: code, Detail: err.Error(), status: status, err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func (e *Error) Status() int {
+	return e.status
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// statusCodes are used for errors carrying only a status, e.g. the ones of stock-commons
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          CodeInvalidRequest,
+	http.StatusUnauthorized:        CodeUnauthorized,
+	http.StatusForbidden:           CodeForbidden,
+	http.StatusNotFound:            CodeNotFound,
+	http.StatusMethodNotAllowed:    CodeMethodNotAllowed,
+	http.StatusFailedDependency:    CodeProviderUnavailable,
+	http.StatusTooManyRequests:     CodeRateLimited,
+	http.StatusBadGateway:          CodeProviderUnavailable,
+	http.StatusInternalServerError: CodeInternal,
+}
+
+//From converts the error to a problem, the detail of internal errors is not disclosed
+func From(err error) Problem {
+	var result Problem
+
+	var coded *Error
+	var withStatus interface{ Status() int }
+
+	switch {
+	case errors.As(err, &coded):
+		result = Problem{Status: coded.status, Code: coded.Code, Detail: coded.Detail}
+	case errors.As(err, &withStatus):
+		result = Problem{Status: withStatus.Status(), Code: statusCodes[withStatus.Status()], Detail: err.Error()}
+	default:
+		result = Problem{Status: http.StatusInternalServerError}
+	}
+
+	if result.Code == "" {
+		result.Code = CodeInternal
+	}
+
+	if result.Status >= http.StatusInternalServerError && result.Code == CodeInternal {
+		result.Detail = ""
+	}
+
+	result.Type = TypeBase + result.Code
+	result.Title = http.StatusText(result.Status)
+
+	return result
+}
+
+//Write answers the request with the problem of the error
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	p := From(err)
+	p.Instance = r.URL.Path
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		logrus.Errorln(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	w.Write(body)
+}
+
+//NotFound answers the requests of unknown routes
+func NotFound() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, New(http.StatusNotFound, CodeNotFound, "no route for ["+r.URL.Path+"]"))
+	})
+}
+
+//MethodNotAllowed answers the requests with a method the route doesn't handle
+func MethodNotAllowed() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method ["+r.Method+"] is not allowed"))
+	})
+}
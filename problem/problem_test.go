@@ -0,0 +1,57 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+)
+
+func TestFrom(t *testing.T) {
+	t.Run("keeps the code of wrapped errors", func(t *testing.T) {
+		err := fmt.Errorf("registering: %w", New(http.StatusNotFound, CodeStockNotFound, "stock [AAPL] is not registered"))
+
+		p := From(err)
+
+		if p.Status != http.StatusNotFound || p.Code != CodeStockNotFound || p.Type != TypeBase+CodeStockNotFound || p.Title != "Not Found" {
+			t.Fatalf("unexpected problem %+v", p)
+		}
+	})
+	t.Run("maps the status of other errors to a generic code", func(t *testing.T) {
+		p := From(stockHttp.NewBadRequestError("bad"))
+
+		if p.Status != http.StatusBadRequest || p.Code != CodeInvalidRequest || p.Detail != "bad" {
+			t.Fatalf("unexpected problem %+v", p)
+		}
+	})
+	t.Run("hides the detail of internal errors", func(t *testing.T) {
+		p := From(errors.New("mongo: no documents in result"))
+
+		if p.Status != http.StatusInternalServerError || p.Code != CodeInternal || p.Detail != "" {
+			t.Fatalf("unexpected problem %+v", p)
+		}
+	})
+}
+
+func TestWrite(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	Write(rec, httptest.NewRequest(http.MethodGet, "/stocks/$$", nil), New(http.StatusBadRequest, CodeInvalidSymbol, "invalid symbol"))
+
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("unexpected response [%d] [%s]", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var p Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Code != CodeInvalidSymbol || p.Instance != "/stocks/$$" || p.Status != http.StatusBadRequest {
+		t.Fatalf("unexpected problem %+v", p)
+	}
+}
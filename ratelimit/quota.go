@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/database"
+)
+
+//QuotaError is returned when the daily quota of the caller is used up
+type QuotaError struct {
+	Name       string
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("daily %s quota of %d is used up", e.Name, e.Limit)
+}
+
+func (e *QuotaError) Status() int {
+	return http.StatusTooManyRequests
+}
+
+//Quota limits how many times a caller can do something per UTC day
+type Quota struct {
+	store *database.Quotas
+	name  string
+	limit int
+}
+
+//NewQuota creates a daily quota, a zero limit disables it
+func NewQuota(store *database.Quotas, name string, limit int) *Quota {
+	return &Quota{
+		store: store,
+		name:  name,
+		limit: limit,
+	}
+}
+
+//Take uses a unit of the quota of the caller in the context, release gives it back if the action failed
+func (q *Quota) Take(ctx context.Context) (release func(), err error) {
+	if q == nil || q.limit <= 0 {
+		return func() {}, nil
+	}
+
+	now := time.Now().UTC()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	key := fmt.Sprintf("%s:%s:%s", q.name, caller(ctx), now.Format("2006-01-02"))
+
+	ok, err := q.store.Take(ctx, key, q.limit, tomorrow)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, &QuotaError{Name: q.name, Limit: q.limit, RetryAfter: tomorrow.Sub(now)}
+	}
+
+	return func() {
+		if err := q.store.Release(context.Background(), key); err != nil {
+			logrus.WithField("quota", key).Warnf("Failed to release quota: %v\n", err)
+		}
+	}, nil
+}
+
+func caller(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		return p.Method + ":" + p.Subject
+	}
+
+	return "anonymous"
+}
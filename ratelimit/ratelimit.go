@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/time/rate"
+
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/problem"
+)
+
+// buckets not used for this long are dropped, a new one starts full anyway
+const idleTimeout = 10 * time.Minute
+
+//Limiter keeps a token bucket per client
+type Limiter struct {
+	limit     rate.Limit
+	burst     int
+	mux       sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+//NewLimiter allows perSecond requests on average and burst at once per client, a zero rate disables limiting
+func NewLimiter(perSecond float64, burst int) *Limiter {
+	return &Limiter{
+		limit:   rate.Limit(perSecond),
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+//Allow takes a token of the client, if there is none it returns the time until the next one
+func (l *Limiter) Allow(client string, now time.Time) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[client] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Duration(float64(time.Second) / float64(l.limit))
+	}
+
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTimeout {
+		return
+	}
+	l.lastSweep = now
+
+	for client, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleTimeout {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+//Budgets maps "METHOD /path/template" of the routes to their limiter, other routes use the default
+type Budgets struct {
+	Default *Limiter
+	Routes  map[string]*Limiter
+	// the client ip is read from X-Forwarded-For, only safe behind a proxy setting it
+	TrustForwarded bool
+}
+
+//Limiter returns the limiter of the "METHOD /path/template" route, it is the default for the other routes
+func (b Budgets) Limiter(route string) *Limiter {
+	if limiter, ok := b.Routes[route]; ok {
+		return limiter
+	}
+
+	return b.Default
+}
+
+func (b Budgets) limiter(r *http.Request) *Limiter {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return b.Limiter(r.Method + " " + template)
+		}
+	}
+
+	return b.Default
+}
+
+//Middleware answers 429 when the client ran out of its budget for the route.
+//Clients are told apart by their api key or user, anonymous ones by their ip.
+func Middleware(budgets Budgets) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limiter := budgets.limiter(r)
+
+			if limiter == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if ok, retryAfter := limiter.Allow(client(r, budgets.TrustForwarded), time.Now()); !ok {
+				TooManyRequests(w, r, problem.New(http.StatusTooManyRequests, problem.CodeRateLimited, "rate limit exceeded"), retryAfter)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func client(r *http.Request, trustForwarded bool) string {
+	if p, ok := auth.FromContext(r.Context()); ok && p.Method != auth.MethodNone {
+		return p.Method + ":" + p.Subject
+	}
+
+	if trustForwarded {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return "ip:" + strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+//TooManyRequests writes 429 with the seconds to wait in Retry-After
+func TooManyRequests(w http.ResponseWriter, r *http.Request, err error, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	problem.Write(w, r, err)
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("rejects after the burst until a token is refilled", func(t *testing.T) {
+		limiter := NewLimiter(1, 2)
+		now := time.Now()
+
+		for i := 0; i < 2; i++ {
+			if ok, _ := limiter.Allow("ip:10.0.0.1", now); !ok {
+				t.Fatalf("request [%d] should be allowed", i)
+			}
+		}
+
+		ok, retryAfter := limiter.Allow("ip:10.0.0.1", now)
+		if ok || retryAfter <= 0 || retryAfter > time.Second {
+			t.Fatalf("expected rejection with retry within a second, got [%v] [%v]", ok, retryAfter)
+		}
+
+		if ok, _ := limiter.Allow("ip:10.0.0.2", now); !ok {
+			t.Fatal("other clients have their own bucket")
+		}
+
+		if ok, _ := limiter.Allow("ip:10.0.0.1", now.Add(time.Second)); !ok {
+			t.Fatal("expected a refilled token")
+		}
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("uses the budget of the route and answers 429 with Retry-After", func(t *testing.T) {
+		router := mux.NewRouter()
+		router.Use(Middleware(Budgets{
+			Default: NewLimiter(100, 100),
+			Routes:  map[string]*Limiter{"POST /stocks/{symbol}": NewLimiter(0.1, 1)},
+		}))
+		ok := func(w http.ResponseWriter, r *http.Request) {}
+		router.HandleFunc("/stocks/{symbol}", ok).Methods(http.MethodGet, http.MethodPost)
+
+		statuses := []int{}
+		for _, method := range []string{http.MethodPost, http.MethodGet, http.MethodPost} {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(method, "/stocks/INTC", nil))
+			statuses = append(statuses, rec.Code)
+
+			if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "10" {
+				t.Fatalf("expected Retry-After 10, got [%s]", rec.Header().Get("Retry-After"))
+			}
+		}
+
+		if statuses[0] != http.StatusOK || statuses[1] != http.StatusOK || statuses[2] != http.StatusTooManyRequests {
+			t.Fatalf("unexpected statuses %v", statuses)
+		}
+	})
+	t.Run("counts OPTIONS requests against the default budget", func(t *testing.T) {
+		router := mux.NewRouter()
+		router.Use(Middleware(Budgets{Default: NewLimiter(0.1, 1)}))
+		ok := func(w http.ResponseWriter, r *http.Request) {}
+		router.HandleFunc("/stocks/{symbol}", ok).Methods(http.MethodOptions)
+
+		statuses := []int{}
+		for i := 0; i < 2; i++ {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/stocks/INTC", nil))
+			statuses = append(statuses, rec.Code)
+		}
+
+		if statuses[0] != http.StatusOK || statuses[1] != http.StatusTooManyRequests {
+			t.Fatalf("unexpected statuses %v", statuses)
+		}
+	})
+}
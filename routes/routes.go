@@ -2,28 +2,139 @@ package routes
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/nagymarci/stock-screener/handler"
 
 	"github.com/gorilla/mux"
+	"github.com/nagymarci/stock-screener/auth"
 	"github.com/nagymarci/stock-screener/controllers"
+	"github.com/nagymarci/stock-screener/cors"
+	"github.com/nagymarci/stock-screener/events"
+	"github.com/nagymarci/stock-screener/graph"
+	"github.com/nagymarci/stock-screener/health"
+	"github.com/nagymarci/stock-screener/metrics"
+	"github.com/nagymarci/stock-screener/openapi"
+	"github.com/nagymarci/stock-screener/problem"
+	"github.com/nagymarci/stock-screener/ratelimit"
+	"github.com/nagymarci/stock-screener/settings"
+	"github.com/nagymarci/stock-screener/watch"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/urfave/negroni"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 )
 
+//Version prefixes the paths of the api
+const Version = "/v1"
+
+// the api was served without version first, these paths are kept as deprecated aliases of v1
+var unversioned = []string{"/stocks", "/admin"}
+
 //Route configures the routing
-func Route(controller *controllers.Controller) http.Handler {
+func Route(controller *controllers.Controller, checker *health.Checker, manager *settings.Manager, authenticator auth.Authenticator, keys *auth.Keys, corsOptions cors.Options, budgets ratelimit.Budgets, validator *openapi.Validator, changes *watch.Hub, webhooks *events.Webhooks) http.Handler {
+	c := cors.New(corsOptions)
+	router := newRouter(controller, checker, manager, authenticator, keys, budgets, validator, changes, webhooks, c.OriginAllowed)
+
+	recovery := negroni.NewRecovery()
+	recovery.PrintStack = false
+
+	// preflight requests are answered before the router, which only allows OPTIONS on a few routes
+	n := negroni.New(recovery, negroni.NewLogger(), c)
+	n.UseHandler(versioned(router))
+	return n
+}
+
+func newRouter(controller *controllers.Controller, checker *health.Checker, manager *settings.Manager, authenticator auth.Authenticator, keys *auth.Keys, budgets ratelimit.Budgets, validator *openapi.Validator, changes *watch.Hub, webhooks *events.Webhooks, originAllowed func(string) bool) *mux.Router {
 	router := mux.NewRouter()
+	router.NotFoundHandler = problem.NotFound()
+	router.MethodNotAllowedHandler = problem.MethodNotAllowed()
+	// browsers can't set headers on the streams, they pass the token in the url which shouldn't be traced
+	router.Use(auth.QueryToken("GET /v1/stocks/stream", "GET /v1/stocks/live"))
+	router.Use(otelmux.Middleware("stock-screener"))
+	router.Use(metrics.Middleware)
+	router.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+	handler.HealthHandler(router)
+	handler.ReadyHandler(router, checker)
+	handler.OpenAPIHandler(router)
+	handler.SwaggerUIHandler(router)
+
+	router.Use(auth.Authenticate(authenticator))
+
+	v1 := router.PathPrefix(Version).Subrouter()
 
-	stocks := router.PathPrefix("/stocks").Subrouter()
+	stocks := v1.PathPrefix("/stocks").Subrouter()
+	stocks.Use(auth.Require(auth.Rules{
+		Default: auth.RoleViewer,
+		Routes: map[string]auth.Role{
+			"POST /v1/stocks/{symbol}":          auth.RoleEditor,
+			"POST /v1/stocks/import":            auth.RoleEditor,
+			"DELETE /v1/stocks/{symbol}":        auth.RoleAdmin,
+			"PUT /v1/stocks/{symbol}/overrides": auth.RoleAdmin,
+		},
+	}))
+	stocks.Use(ratelimit.Middleware(budgets))
+	stocks.Use(validator.Requests())
+	handler.ExportStocksHandler(stocks, controller)
+	handler.StreamStocksHandler(stocks, changes)
+	handler.LiveStocksHandler(stocks, controller, changes, originAllowed)
+	handler.ImportStocksHandler(stocks, controller)
 	handler.RegisterStockHandler(stocks, controller)
+	handler.GetStockChangesHandler(stocks, controller)
+	handler.SetOverridesHandler(stocks, controller)
 	handler.GetStockInfoHandler(stocks, controller)
 	handler.DeleteStockHandler(stocks, controller)
 	handler.GetAllStocksHandler(stocks, controller)
 
-	recovery := negroni.NewRecovery()
-	recovery.PrintStack = false
+	admin := v1.PathPrefix("/admin").Subrouter()
+	admin.Use(auth.Require(auth.Rules{Default: auth.RoleAdmin}))
+	admin.Use(validator.Requests())
+	handler.GetQuarantineHandler(admin, controller)
+	handler.ApproveQuarantineHandler(admin, controller)
+	handler.RejectQuarantineHandler(admin, controller)
+	handler.GetSettingsHistoryHandler(admin, manager)
+	handler.GetSettingsHandler(admin, manager)
+	handler.UpdateSettingsHandler(admin, manager)
+	handler.GetAPIKeysHandler(admin, keys)
+	handler.IssueAPIKeyHandler(admin, keys)
+	handler.RotateAPIKeyHandler(admin, keys)
+	handler.RevokeAPIKeyHandler(admin, keys)
+	handler.GetDeadLettersHandler(admin, webhooks)
+	handler.RedeliverHandler(admin, webhooks)
+	handler.GetWebhooksHandler(admin, webhooks)
+	handler.CreateWebhookHandler(admin, webhooks)
+	handler.DeleteWebhookHandler(admin, webhooks)
 
-	n := negroni.New(recovery, negroni.NewLogger())
-	n.UseHandler(router)
-	return n
+	// the graphql schema evolves by adding fields, so it isn't versioned with the paths
+	graphQL := router.PathPrefix("/graphql").Subrouter()
+	graphQL.Use(auth.Require(auth.Rules{Default: auth.RoleViewer}))
+	graphQL.Use(ratelimit.Middleware(budgets))
+	graphQL.Use(validator.Requests())
+	handler.GraphQLHandler(graphQL, graph.New(controller))
+
+	return router
+}
+
+// versioned serves the unversioned paths with v1 and marks their responses deprecated
+func versioned(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, prefix := range unversioned {
+			if r.URL.Path != prefix && !strings.HasPrefix(r.URL.Path, prefix+"/") {
+				continue
+			}
+
+			w.Header().Set("Deprecation", "true")
+
+			u := *r.URL
+			u.Path = Version + u.Path
+			if u.RawPath != "" {
+				u.RawPath = Version + u.RawPath
+			}
+
+			r = r.WithContext(r.Context())
+			r.URL = &u
+			break
+		}
+
+		next.ServeHTTP(w, r)
+	})
 }
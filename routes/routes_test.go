@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/health"
+	"github.com/nagymarci/stock-screener/openapi"
+	"github.com/nagymarci/stock-screener/ratelimit"
+)
+
+func TestRoutesAreDocumented(t *testing.T) {
+	validator, err := openapi.NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := newRouter(nil, health.New(time.Second), nil, auth.Anonymous(), nil, ratelimit.Budgets{}, validator, nil, nil, nil)
+
+	served := map[string]bool{}
+
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		methods, methodsErr := route.GetMethods()
+		if err != nil || methodsErr != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			if method == http.MethodOptions {
+				continue
+			}
+
+			served[method+" "+template] = true
+
+			if doc.Paths.Find(template) == nil || doc.Paths.Find(template).GetOperation(method) == nil {
+				t.Errorf("[%s %s] is missing from the document", method, template)
+			}
+		}
+
+		return nil
+	})
+
+	for path, item := range doc.Paths {
+		for method := range item.Operations() {
+			if !served[method+" "+path] {
+				t.Errorf("[%s %s] is documented but not served", method, path)
+			}
+		}
+	}
+}
+
+func TestUnversionedPaths(t *testing.T) {
+	var path string
+	handler := versioned(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stocks/AAPL", nil))
+
+	if path != "/v1/stocks/AAPL" || rec.Header().Get("Deprecation") != "true" {
+		t.Fatalf("expected deprecated alias of v1, got [%s]", path)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stocksfoo", nil))
+
+	if path != "/stocksfoo" || rec.Header().Get("Deprecation") != "" {
+		t.Fatalf("unexpected rewrite to [%s]", path)
+	}
+}
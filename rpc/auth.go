@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/nagymarci/stock-screener/auth"
+	pb "github.com/nagymarci/stock-screener/proto/stockscreener/v1"
+)
+
+// required are the roles of the methods, the others require the viewer role like the REST reads
+var required = map[string]auth.Role{
+	pb.StockService_RegisterStock_FullMethodName: auth.RoleEditor,
+	pb.StockService_DeleteStock_FullMethodName:   auth.RoleAdmin,
+}
+
+// credentials are the metadata keys copied to the headers read by the authenticators
+var credentials = map[string]string{
+	"authorization": "Authorization",
+	"x-api-key":     auth.APIKeyHeader,
+}
+
+// authenticate puts the caller into the context, it fails when the caller doesn't have the role of the method
+func authenticate(ctx context.Context, authenticator auth.Authenticator, method string) (context.Context, error) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, method, nil)
+	if err != nil {
+		return ctx, toStatus(err)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, header := range credentials {
+		if values := md.Get(key); len(values) > 0 {
+			r.Header.Set(header, values[0])
+		}
+	}
+
+	p, ok, err := authenticator.Authenticate(r)
+	if err != nil {
+		logrus.WithField("method", method).Warnf("Authentication failed: %v\n", err)
+		return ctx, toStatus(auth.NewUnauthorizedError(err.Error()))
+	}
+
+	if !ok {
+		return ctx, toStatus(auth.NewUnauthorizedError("authentication required"))
+	}
+
+	role, ok := required[method]
+	if !ok {
+		role = auth.RoleViewer
+	}
+
+	if p.Role < role {
+		logrus.WithField("subject", p.Subject).Warnf("Denied [%s] with role [%s]\n", method, p.Role)
+		return ctx, toStatus(auth.NewForbiddenError(fmt.Sprintf("role [%s] is required", role)))
+	}
+
+	return auth.WithPrincipal(ctx, p), nil
+}
+
+func unaryAuth(authenticator auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, authenticator, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(authenticator auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), authenticator, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authenticatedStream carries the caller in the context of the stream
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
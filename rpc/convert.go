@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"errors"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+	pb "github.com/nagymarci/stock-screener/proto/stockscreener/v1"
+	"github.com/nagymarci/stock-screener/ratelimit"
+	"github.com/nagymarci/stock-screener/watch"
+)
+
+//ErrorDomain is the domain of the ErrorInfo detail, its reason is the problem code of the REST api
+const ErrorDomain = "stock-screener"
+
+var statusCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusUnprocessableEntity: codes.FailedPrecondition,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusBadGateway:          codes.Unavailable,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+}
+
+var changeKinds = map[watch.Kind]pb.StockChange_Kind{
+	watch.KindRegistered: pb.StockChange_KIND_REGISTERED,
+	watch.KindUpdated:    pb.StockChange_KIND_UPDATED,
+	watch.KindDeleted:    pb.StockChange_KIND_DELETED,
+}
+
+// toStatus converts the error to the status of the same problem the REST api answers with
+func toStatus(err error) error {
+	var quotaErr *ratelimit.QuotaError
+	if errors.As(err, &quotaErr) {
+		err = problem.Wrap(http.StatusTooManyRequests, problem.CodeQuotaExceeded, err)
+	}
+
+	p := problem.From(err)
+
+	code, ok := statusCodes[p.Status]
+	if !ok {
+		code = codes.Internal
+	}
+
+	message := p.Detail
+	if message == "" {
+		message = p.Title
+	}
+
+	s, detailErr := status.New(code, message).WithDetails(&errdetails.ErrorInfo{Reason: p.Code, Domain: ErrorDomain})
+	if detailErr != nil {
+		return status.Error(code, message)
+	}
+
+	return s.Err()
+}
+
+func toStock(s model.StockDataInfo) *pb.Stock {
+	return &pb.Stock{
+		Ticker:   s.Ticker,
+		Price:    s.Price,
+		Eps:      s.Eps,
+		Dividend: s.Dividend,
+		PeRatioHistory: &pb.PeRatioHistory{
+			Avg:         s.PeRatio5yr.Avg,
+			Min:         s.PeRatio5yr.Min,
+			LastUpdated: timestamppb.New(s.PeRatio5yr.LastUpdated),
+			Source:      s.PeRatio5yr.Source,
+		},
+		DividendYieldHistory: &pb.DividendYieldHistory{
+			Avg:         s.DividendYield5yr.Avg,
+			Max:         s.DividendYield5yr.Max,
+			LastUpdated: timestamppb.New(s.DividendYield5yr.LastUpdated),
+			Source:      s.DividendYield5yr.Source,
+		},
+		LastUpdated: timestamppb.New(s.LastUpdated),
+		Source:      s.Source,
+		Stale:       s.Stale,
+	}
+}
+
+func toChange(c watch.Change) *pb.StockChange {
+	return &pb.StockChange{
+		Kind:  changeKinds[c.Kind],
+		Stock: toStock(c.Stock),
+		Time:  timestamppb.New(c.Time),
+	}
+}
@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/problem"
+	pb "github.com/nagymarci/stock-screener/proto/stockscreener/v1"
+	"github.com/nagymarci/stock-screener/ratelimit"
+)
+
+// routes are the REST routes whose budget the methods share, the others use the default budget like the REST reads
+var routes = map[string]string{
+	pb.StockService_RegisterStock_FullMethodName: "POST /v1/stocks/{symbol}",
+}
+
+// unaryRateLimit answers RESOURCE_EXHAUSTED when the caller ran out of the budget of the method,
+// the seconds to wait are sent in the retry-after header. It runs after the authentication.
+func unaryRateLimit(budgets ratelimit.Budgets) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		limiter := budgets.Limiter(routes[info.FullMethod])
+
+		if limiter == nil {
+			return handler(ctx, req)
+		}
+
+		if ok, retryAfter := limiter.Allow(client(ctx), time.Now()); !ok {
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))))
+			return nil, toStatus(problem.New(http.StatusTooManyRequests, problem.CodeRateLimited, "rate limit exceeded"))
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// client tells the callers apart like the REST api, so they share their budgets on both
+func client(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok && p.Method != auth.MethodNone {
+		return p.Method + ":" + p.Subject
+	}
+
+	host := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		host = p.Addr.String()
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+
+	return "ip:" + host
+}
@@ -0,0 +1,201 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/controllers"
+	"github.com/nagymarci/stock-screener/database"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+	pb "github.com/nagymarci/stock-screener/proto/stockscreener/v1"
+	"github.com/nagymarci/stock-screener/ratelimit"
+	"github.com/nagymarci/stock-screener/watch"
+)
+
+const (
+	maxPageSize = 1000
+	// changes queued for a watcher before its stream is ended
+	watchBuffer = 64
+)
+
+//Server serves the StockService, callers are authenticated and authorized like on the REST api
+type Server struct {
+	*grpc.Server
+	service *server
+}
+
+type server struct {
+	pb.UnimplementedStockServiceServer
+	controller *controllers.Controller
+	changes    *watch.Hub
+	// closed on shutdown, the watches would keep the graceful stop waiting otherwise
+	stopping chan struct{}
+}
+
+//New returns the server of the StockService, the calls are rate limited with the budgets of the REST api
+func New(controller *controllers.Controller, changes *watch.Hub, authenticator auth.Authenticator, budgets ratelimit.Budgets) *Server {
+	s := &Server{
+		Server: grpc.NewServer(
+			grpc.ChainUnaryInterceptor(unaryAuth(authenticator), unaryRateLimit(budgets)),
+			grpc.ChainStreamInterceptor(streamAuth(authenticator)),
+		),
+		service: &server{controller: controller, changes: changes, stopping: make(chan struct{})},
+	}
+
+	pb.RegisterStockServiceServer(s.Server, s.service)
+
+	return s
+}
+
+//Shutdown ends the watches and waits for the running calls until the context is done
+func (s *Server) Shutdown(ctx context.Context) {
+	close(s.service.stopping)
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+func (s *server) RegisterStock(ctx context.Context, request *pb.RegisterStockRequest) (*pb.Stock, error) {
+	symbol, err := parseSymbol(request.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	log := logrus.WithField("symbol", symbol)
+
+	if err := s.controller.RegisterStock(ctx, symbol); err != nil {
+		log.Errorln(err)
+		return nil, toStatus(err)
+	}
+
+	stock, err := s.controller.GetStockInfo(ctx, symbol)
+	if err != nil {
+		log.Errorln(err)
+		return nil, toStatus(err)
+	}
+
+	return toStock(stock), nil
+}
+
+func (s *server) GetStock(ctx context.Context, request *pb.GetStockRequest) (*pb.Stock, error) {
+	symbol, err := parseSymbol(request.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	stock, err := s.controller.GetStockInfo(ctx, symbol)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toStock(stock), nil
+}
+
+func (s *server) ListStocks(ctx context.Context, request *pb.ListStocksRequest) (*pb.ListStocksResponse, error) {
+	if request.PageSize < 0 || request.PageSize > maxPageSize {
+		return nil, invalid(problem.CodeInvalidQuery, "page_size must be between 0 and 1000")
+	}
+
+	query := database.Query{Limit: int(request.PageSize), Cursor: request.PageToken}
+	if request.Sort != "" {
+		if err := query.SetSort(request.Sort); err != nil {
+			return nil, invalid(problem.CodeInvalidQuery, err.Error())
+		}
+	}
+
+	page, err := s.controller.GetAllStocks(ctx, query)
+	if err != nil {
+		logrus.Errorln(err)
+		return nil, toStatus(err)
+	}
+
+	response := &pb.ListStocksResponse{NextPageToken: page.NextCursor}
+	for _, stock := range page.Stocks {
+		response.Stocks = append(response.Stocks, toStock(stock))
+	}
+
+	return response, nil
+}
+
+func (s *server) DeleteStock(ctx context.Context, request *pb.DeleteStockRequest) (*emptypb.Empty, error) {
+	symbol, err := parseSymbol(request.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.controller.DeleteStock(ctx, symbol); err != nil {
+		logrus.WithField("symbol", symbol).Errorln(err)
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) WatchStocks(request *pb.WatchStocksRequest, stream pb.StockService_WatchStocksServer) error {
+	tickers := make([]string, 0, len(request.Symbols))
+	for _, value := range request.Symbols {
+		symbol, err := parseSymbol(value)
+		if err != nil {
+			return err
+		}
+		tickers = append(tickers, symbol.String())
+	}
+
+	subscription := s.changes.Subscribe(tickers, watchBuffer)
+	defer subscription.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case change, open := <-subscription.Changes():
+			if !open {
+				if errors.Is(subscription.Err(), watch.ErrLagged) {
+					return status.Error(codes.ResourceExhausted, "too many changes queued, watch again")
+				}
+				// the hub may be closed by the http server before the watches are stopped
+				if errors.Is(subscription.Err(), watch.ErrClosed) {
+					return status.Error(codes.Unavailable, "server is shutting down")
+				}
+				return nil
+			}
+
+			if err := stream.Send(toChange(change)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func parseSymbol(value string) (model.Symbol, error) {
+	symbol, err := model.ParseSymbol(value)
+	if err != nil {
+		return symbol, invalid(problem.CodeInvalidSymbol, err.Error())
+	}
+
+	return symbol, nil
+}
+
+func invalid(code, detail string) error {
+	return toStatus(problem.New(http.StatusBadRequest, code, detail))
+}
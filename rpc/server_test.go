@@ -0,0 +1,195 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/nagymarci/stock-screener/auth"
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/problem"
+	pb "github.com/nagymarci/stock-screener/proto/stockscreener/v1"
+	"github.com/nagymarci/stock-screener/ratelimit"
+	"github.com/nagymarci/stock-screener/watch"
+)
+
+func newClient(t *testing.T, changes *watch.Hub, budgets ratelimit.Budgets) pb.StockServiceClient {
+	listener := bufconn.Listen(1 << 20)
+
+	server := New(nil, changes, auth.NewStaticKeyAuthenticator([]auth.StaticKey{
+		{Name: "dashboard", Key: "viewer-key", Role: auth.RoleViewer},
+		{Name: "importer", Key: "editor-key", Role: auth.RoleEditor},
+	}), budgets)
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Shutdown(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewStockServiceClient(conn)
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func TestWatchStocks(t *testing.T) {
+	changes := watch.NewHub()
+	client := newClient(t, changes, ratelimit.Budgets{})
+
+	ctx, cancel := context.WithCancel(withKey("viewer-key"))
+	defer cancel()
+
+	stream, err := client.WatchStocks(ctx, &pb.WatchStocksRequest{Symbols: []string{"intc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the subscription is made when the server receives the request, publish until the change arrives
+	received := make(chan *pb.StockChange)
+	go func() {
+		change, err := stream.Recv()
+		if err == nil {
+			received <- change
+		}
+	}()
+
+	for {
+		changes.Publish(watch.KindUpdated, model.StockDataInfo{Ticker: "MSFT"})
+		changes.Publish(watch.KindUpdated, model.StockDataInfo{Ticker: "INTC", Price: 100})
+
+		select {
+		case change := <-received:
+			if change.Kind != pb.StockChange_KIND_UPDATED || change.Stock.Ticker != "INTC" || change.Stock.Price != 100 {
+				t.Fatalf("unexpected change [%v]", change)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	client := newClient(t, watch.NewHub(), ratelimit.Budgets{})
+
+	t.Run("requires credentials", func(t *testing.T) {
+		_, err := client.GetStock(context.Background(), &pb.GetStockRequest{Symbol: "INTC"})
+
+		if status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("expected unauthenticated, got [%v]", err)
+		}
+	})
+
+	t.Run("requires the role of the method", func(t *testing.T) {
+		_, err := client.DeleteStock(withKey("viewer-key"), &pb.DeleteStockRequest{Symbol: "INTC"})
+
+		if status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("expected permission denied, got [%v]", err)
+		}
+	})
+
+	t.Run("rejects invalid symbols with the problem code", func(t *testing.T) {
+		_, err := client.GetStock(withKey("viewer-key"), &pb.GetStockRequest{Symbol: "NOT A SYMBOL"})
+
+		s := status.Convert(err)
+		if s.Code() != codes.InvalidArgument {
+			t.Fatalf("expected invalid argument, got [%v]", err)
+		}
+
+		if reason(s) != problem.CodeInvalidSymbol {
+			t.Fatalf("expected reason [%s], got [%s]", problem.CodeInvalidSymbol, reason(s))
+		}
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	// a single call per caller and budget, the invalid symbols are rejected before the controller
+	client := newClient(t, watch.NewHub(), ratelimit.Budgets{
+		Default: ratelimit.NewLimiter(0.001, 1),
+		Routes:  map[string]*ratelimit.Limiter{"POST /v1/stocks/{symbol}": ratelimit.NewLimiter(0.001, 1)},
+	})
+
+	register := func(key string) error {
+		_, err := client.RegisterStock(withKey(key), &pb.RegisterStockRequest{Symbol: "NOT A SYMBOL"})
+		return err
+	}
+	get := func(key string) error {
+		_, err := client.GetStock(withKey(key), &pb.GetStockRequest{Symbol: "NOT A SYMBOL"})
+		return err
+	}
+
+	if err := register("editor-key"); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected invalid argument, got [%v]", err)
+	}
+
+	t.Run("limits the registrations with the provider budget", func(t *testing.T) {
+		var header metadata.MD
+		_, err := client.RegisterStock(withKey("editor-key"), &pb.RegisterStockRequest{Symbol: "NOT A SYMBOL"}, grpc.Header(&header))
+
+		s := status.Convert(err)
+		if s.Code() != codes.ResourceExhausted || reason(s) != problem.CodeRateLimited {
+			t.Fatalf("expected rate limited, got [%v]", err)
+		}
+
+		if len(header.Get("retry-after")) != 1 {
+			t.Fatalf("expected retry-after, got [%v]", header)
+		}
+	})
+
+	t.Run("keeps the default budget for the other methods", func(t *testing.T) {
+		if err := get("editor-key"); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected invalid argument, got [%v]", err)
+		}
+
+		if err := get("editor-key"); status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("expected resource exhausted, got [%v]", err)
+		}
+	})
+
+	t.Run("keeps the budgets per caller", func(t *testing.T) {
+		if err := get("viewer-key"); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected invalid argument, got [%v]", err)
+		}
+	})
+}
+
+func TestToStatus(t *testing.T) {
+	s := status.Convert(toStatus(problem.New(http.StatusNotFound, problem.CodeStockNotFound, "stock [INTC] is not registered")))
+
+	if s.Code() != codes.NotFound || s.Message() != "stock [INTC] is not registered" || reason(s) != problem.CodeStockNotFound {
+		t.Fatalf("unexpected status [%v]", s)
+	}
+
+	t.Run("hides internal errors", func(t *testing.T) {
+		s := status.Convert(toStatus(context.DeadlineExceeded))
+
+		if s.Code() != codes.Internal || reason(s) != problem.CodeInternal || s.Message() != http.StatusText(http.StatusInternalServerError) {
+			t.Fatalf("unexpected status [%v]", s)
+		}
+	})
+}
+
+func reason(s *status.Status) string {
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+
+	return ""
+}
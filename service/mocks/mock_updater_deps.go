@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/nagymarci/stock-screener/model"
 	reflect "reflect"
@@ -34,16 +35,16 @@ func (m *MockgetStockWithFields) EXPECT() *MockgetStockWithFieldsMockRecorder {
 }
 
 // GetWithFields mocks base method
-func (m *MockgetStockWithFields) GetWithFields(symbol string, fields []string) (model.StockDataInfo, error) {
+func (m *MockgetStockWithFields) GetWithFields(ctx context.Context, symbol string, fields []string) (model.StockDataInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetWithFields", symbol, fields)
+	ret := m.ctrl.Call(m, "GetWithFields", ctx, symbol, fields)
 	ret0, _ := ret[0].(model.StockDataInfo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetWithFields indicates an expected call of GetWithFields
-func (mr *MockgetStockWithFieldsMockRecorder) GetWithFields(symbol, fields interface{}) *gomock.Call {
+func (mr *MockgetStockWithFieldsMockRecorder) GetWithFields(ctx, symbol, fields interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithFields", reflect.TypeOf((*MockgetStockWithFields)(nil).GetWithFields), symbol, fields)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithFields", reflect.TypeOf((*MockgetStockWithFields)(nil).GetWithFields), ctx, symbol, fields)
 }
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+)
+
+//Scheduler runs a job on a cron schedule that can be changed at runtime
+type Scheduler struct {
+	mux   sync.Mutex
+	cron  *cron.Cron
+	job   func()
+	spec  string
+	entry cron.EntryID
+}
+
+func NewScheduler(c *cron.Cron, job func()) *Scheduler {
+	return &Scheduler{
+		cron: c,
+		job:  job,
+	}
+}
+
+//Schedule replaces the schedule of the job, a running job is not interrupted
+func (s *Scheduler) Schedule(spec string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if spec == s.spec {
+		return nil
+	}
+
+	entry, err := s.cron.AddFunc(spec, s.job)
+	if err != nil {
+		return err
+	}
+
+	if s.spec != "" {
+		s.cron.Remove(s.entry)
+		logrus.WithField("component", "scheduler").Infof("Rescheduled from [%s] to [%s]\n", s.spec, spec)
+	}
+
+	s.spec = spec
+	s.entry = entry
+
+	return nil
+}
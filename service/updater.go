@@ -1,37 +1,63 @@
 package service
 
 import (
-	"fmt"
+	"context"
 	"math/rand"
 	"sync"
 	"time"
 
+	"github.com/nagymarci/stock-screener/events"
+	"github.com/nagymarci/stock-screener/metrics"
 	"github.com/nagymarci/stock-screener/model"
+	"github.com/nagymarci/stock-screener/tracing"
+	"github.com/nagymarci/stock-screener/validation"
+	"github.com/nagymarci/stock-screener/watch"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/nagymarci/stock-screener/database"
 )
 
 type Updater struct {
-	mux                    sync.Mutex
-	database               *database.Stockinfos
-	stockClient            getStockWithFields
-	stockUpdateInterval    string
-	peUpdateInterval       string
-	divYieldUpdateInterval string
+	mux         sync.Mutex
+	database    *database.Stockinfos
+	quarantine  *database.Quarantine
+	stockClient getStockWithFields
+	rules       validation.Rules
+	changes     *watch.Hub
+	publisher   *events.Publisher
+
+	// guards the settings that can be changed while an update is running
+	settingsMux sync.RWMutex
+	intervals   Intervals
+	concurrency int
+}
+
+//Intervals configures when the fields of an updated stock are fetched again.
+//A random delay up to the jitter is added, so the stocks don't expire at the same time.
+type Intervals struct {
+	Stock         time.Duration
+	PeRatio       time.Duration
+	DividendYield time.Duration
+	StockJitter   time.Duration
+	HistoryJitter time.Duration
 }
 
 type getStockWithFields interface {
-	GetWithFields(symbol string, fields []string) (model.StockDataInfo, error)
+	GetWithFields(ctx context.Context, symbol string, fields []string) (model.StockDataInfo, error)
 }
 
-func New(db *database.Stockinfos, sc getStockWithFields, stockInterval, peInterval, divInterval string) *Updater {
+func New(db *database.Stockinfos, quarantine *database.Quarantine, sc getStockWithFields, rules validation.Rules, intervals Intervals, changes *watch.Hub, publisher *events.Publisher) *Updater {
 	return &Updater{
-		database:               db,
-		stockClient:            sc,
-		stockUpdateInterval:    stockInterval,
-		peUpdateInterval:       peInterval,
-		divYieldUpdateInterval: divInterval,
+		database:    db,
+		quarantine:  quarantine,
+		stockClient: sc,
+		rules:       rules,
+		changes:     changes,
+		publisher:   publisher,
+		intervals:   intervals,
+		concurrency: 1,
 	}
 }
 
@@ -41,52 +67,207 @@ func (u *Updater) UpdateStocks() {
 	u.mux.Lock()
 	defer u.mux.Unlock()
 
-	stocks, err := u.database.GetAllExpired()
+	ctx, span := tracing.Start(context.Background(), "Updater.UpdateStocks")
+	defer span.End()
+
+	start := time.Now()
+
+	// the changes written by the run are recorded with its id
+	cause := model.Cause{Kind: model.CauseUpdater, RunID: primitive.NewObjectID().Hex()}
+	log = log.WithField("run", cause.RunID)
+	span.SetAttributes(attribute.String("run", cause.RunID))
+
+	stocks, err := u.database.GetAllExpired(ctx)
 	if err != nil {
 		log.Errorln(err)
+		tracing.Error(span, err)
 		return
 	}
 	log.Infof("Updating [%d] stocks\n", len(stocks))
+	span.SetAttributes(attribute.Int("expired", len(stocks)))
 
+	defer func() {
+		metrics.ObserveUpdaterRun(time.Since(start), len(stocks))
+	}()
+
+	// taken after the query, so every selected stock has a group expired by now
 	now := time.Now()
 
+	jobs := make(chan model.StockDataInfo)
+	var wg sync.WaitGroup
+	for i := 0; i < u.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for stockInfo := range jobs {
+				metrics.CountUpdatedStock(u.updateStock(ctx, stockInfo, now, cause))
+			}
+		}()
+	}
+
 	for _, stockInfo := range stocks {
-		fields := []string{}
-		if stockInfo.NextUpdate.Before(now) {
-			fields = append(fields, "price", "eps", "div")
-		}
-		if stockInfo.DividendYield5yr.NextUpdate.Before(now) {
-			fields = append(fields, "divHist")
+		jobs <- stockInfo
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// updateStock fetches the expired fields of the stock and returns the result for the metrics
+func (u *Updater) updateStock(ctx context.Context, stockInfo model.StockDataInfo, now time.Time, cause model.Cause) string {
+	log := logrus.WithField("component", "updater").WithField("run", cause.RunID).WithField("ticker", stockInfo.Ticker)
+
+	fields := []string{}
+	if stockInfo.NextUpdate.Before(now) {
+		fields = append(fields, "price", "eps", "div")
+	}
+	if stockInfo.DividendYield5yr.NextUpdate.Before(now) {
+		fields = append(fields, "divHist")
+	}
+	if stockInfo.PeRatio5yr.NextUpdate.Before(now) {
+		fields = append(fields, "pe")
+	}
+
+	// without fields the provider would be asked for every one of them
+	if len(fields) == 0 {
+		log.Debugln("Nothing expired")
+		return metrics.ResultSkipped
+	}
+
+	// the stored ticker is canonical, the provider has its own notation
+	symbol := stockInfo.Ticker
+	if parsed, err := model.ParseSymbol(stockInfo.Ticker); err == nil {
+		symbol = parsed.Provider()
+	}
+
+	newStockInfo, err := u.stockClient.GetWithFields(ctx, symbol, fields)
+	if err != nil {
+		log.Warningln(err)
+
+		if err := u.publisher.Publish(ctx, events.Failed(stockInfo.Ticker, err)); err != nil {
+			log.Errorln(err)
 		}
-		if stockInfo.PeRatio5yr.NextUpdate.Before(now) {
-			fields = append(fields, "pe")
+		return metrics.ResultFailed
+	}
+
+	// updates are matched by ticker, keep the stored canonical one
+	newStockInfo.Ticker = stockInfo.Ticker
+	u.Schedule(&newStockInfo)
+	newStockInfo.ExpireOverrides(stockInfo.Overrides, now)
+	newStockInfo.SetProvenance(time.Now(), model.SourceProvider)
+
+	// the pinned values are kept by the database, so they aren't validated
+	proposed := newStockInfo
+	proposed.Overrides = stockInfo.Overrides
+	proposed.ApplyOverrides(now)
+
+	if violations := u.rules.Validate(stockInfo, proposed, fields); len(violations) > 0 {
+		log.Warnf("Quarantined update %v\n", violations)
+
+		// the fetched groups wait for the next interval, so the provider isn't asked again until the review
+		if err := u.database.Postpone(ctx, stockInfo.Ticker, nextUpdates(newStockInfo, fields)); err != nil {
+			log.Errorln(err)
 		}
 
-		newStockInfo, err := u.stockClient.GetWithFields(stockInfo.Ticker, fields)
+		err = u.quarantine.Save(ctx, model.QuarantineEntry{
+			Ticker:     stockInfo.Ticker,
+			Fields:     fields,
+			Previous:   stockInfo,
+			Proposed:   newStockInfo,
+			Violations: violations,
+			Created:    time.Now(),
+		})
+
 		if err != nil {
-			log.WithField("ticker", stockInfo.Ticker).Warningln(err)
-			continue
+			log.Errorln(err)
+		}
+		return metrics.ResultQuarantined
+	}
+
+	var stored model.StockDataInfo
+	err = u.publisher.Record(ctx, func(ctx context.Context) ([]model.Event, error) {
+		var err error
+		if stored, err = u.database.Update(ctx, newStockInfo, fields, cause); err != nil {
+			return nil, err
 		}
 
-		u.calculateNextUpdateTimes(&newStockInfo)
+		return events.Updated(stored, fields), nil
+	})
+	if err != nil {
+		log.Errorln(err)
+		return metrics.ResultFailed
+	}
+
+	u.changes.Publish(watch.KindUpdated, stored)
+
+	return metrics.ResultUpdated
+}
+
+//Apply changes the intervals and the number of stocks updated in parallel, the running update is not affected
+func (u *Updater) Apply(s model.Settings) {
+	u.settingsMux.Lock()
+	defer u.settingsMux.Unlock()
+
+	u.intervals = Intervals{
+		Stock:         time.Duration(s.StockUpdateInterval),
+		PeRatio:       time.Duration(s.PeUpdateInterval),
+		DividendYield: time.Duration(s.DivUpdateInterval),
+		StockJitter:   time.Duration(s.StockUpdateJitter),
+		HistoryJitter: time.Duration(s.HistoryUpdateJitter),
+	}
+	u.concurrency = s.Concurrency
+}
+
+func (u *Updater) workers() int {
+	u.settingsMux.RLock()
+	defer u.settingsMux.RUnlock()
 
-		u.database.Update(newStockInfo)
+	if u.concurrency < 1 {
+		return 1
 	}
+
+	return u.concurrency
+}
+
+//Schedule sets the next update times of every field group based on the configuration, a nil updater leaves them unset
+func (u *Updater) Schedule(stock *model.StockDataInfo) {
+	if u == nil {
+		return
+	}
+
+	u.settingsMux.RLock()
+	intervals := u.intervals
+	u.settingsMux.RUnlock()
+
+	stockJitter := jitter(intervals.StockJitter)
+	historyJitter := jitter(intervals.HistoryJitter)
+
+	stock.NextUpdate = time.Now().Add(intervals.Stock).Add(stockJitter)
+	stock.PeRatio5yr.NextUpdate = time.Now().Add(intervals.PeRatio).Add(historyJitter)
+	stock.DividendYield5yr.NextUpdate = time.Now().Add(intervals.DividendYield).Add(historyJitter)
 }
 
-//CalculateNextUpdateTimes calculates the next update times based on the configuration
-func (u *Updater) calculateNextUpdateTimes(stock *model.StockDataInfo) {
-	stockUpdateInterval, _ := time.ParseDuration(u.stockUpdateInterval)
-	peUpdateInterval, _ := time.ParseDuration(u.peUpdateInterval)
-	divYieldUpdateInterval, _ := time.ParseDuration(u.divYieldUpdateInterval)
+// nextUpdates returns the next update times of the fetched field groups by the path of their nextUpdate field
+func nextUpdates(stock model.StockDataInfo, fields []string) map[string]time.Time {
+	result := map[string]time.Time{}
 
-	randMinutes := rand.Intn(30)
-	randMinutesInterval, _ := time.ParseDuration(fmt.Sprintf("%dm", randMinutes))
+	for _, field := range fields {
+		switch field {
+		case "price", "eps", "div":
+			result["nextUpdate"] = stock.NextUpdate
+		case "divHist":
+			result["dividendYield5yr.nextUpdate"] = stock.DividendYield5yr.NextUpdate
+		case "pe":
+			result["peRatio5yr.nextUpdate"] = stock.PeRatio5yr.NextUpdate
+		}
+	}
 
-	randHours := rand.Intn(24)
-	randHoursInterval, _ := time.ParseDuration(fmt.Sprintf("%dh", randHours))
+	return result
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
 
-	stock.NextUpdate = time.Now().Add(stockUpdateInterval).Add(randMinutesInterval)
-	stock.PeRatio5yr.NextUpdate = time.Now().Add(peUpdateInterval).Add(randHoursInterval)
-	stock.DividendYield5yr.NextUpdate = time.Now().Add(divYieldUpdateInterval).Add(randHoursInterval)
+	return time.Duration(rand.Int63n(int64(max)))
 }
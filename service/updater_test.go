@@ -8,8 +8,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/nagymarci/stock-screener/events"
+	"github.com/nagymarci/stock-screener/metrics"
 	"github.com/nagymarci/stock-screener/model"
 	"github.com/nagymarci/stock-screener/service/mocks"
+	"github.com/nagymarci/stock-screener/validation"
+	"github.com/nagymarci/stock-screener/watch"
 
 	"github.com/golang/mock/gomock"
 	"github.com/nagymarci/stock-screener/database"
@@ -80,21 +84,25 @@ func TestUpdater(t *testing.T) {
 
 		sDb := database.NewStockinfos(db)
 
-		err := sDb.Save(stockData)
+		err := sDb.Save(context.Background(), stockData, model.Cause{Kind: model.CauseRegistration})
 		if err != nil {
 			t.Fatal(err)
 		}
-		defer sDb.Delete(stockData.Ticker)
+		defer sDb.Delete(context.Background(), model.Symbol{Ticker: stockData.Ticker})
 
 		sSC := mocks.NewMockgetStockWithFields(ctrl)
 		stockData.Price = 100
-		sSC.EXPECT().GetWithFields("INTC", []string{"price", "eps", "div", "divHist", "pe"}).Return(stockData, nil)
+		sSC.EXPECT().GetWithFields(gomock.Any(), "INTC", []string{"price", "eps", "div", "divHist", "pe"}).Return(stockData, nil)
 
-		updater := New(sDb, sSC, "1h", "1h", "1h")
+		changes := watch.NewHub()
+		subscription := changes.Subscribe([]string{"INTC"}, 1)
+		defer subscription.Close()
+
+		updater := New(sDb, database.NewQuarantine(db), sSC, validation.Rules{}, Intervals{Stock: time.Hour, PeRatio: time.Hour, DividendYield: time.Hour}, changes, nil)
 
 		updater.UpdateStocks()
 
-		result, err := sDb.Get(stockData.Ticker)
+		result, err := sDb.Get(context.Background(), model.Symbol{Ticker: stockData.Ticker})
 
 		if err != nil {
 			t.Fatal(err)
@@ -103,6 +111,20 @@ func TestUpdater(t *testing.T) {
 		if result.Price != 100 {
 			t.Fatalf("stock is not updated")
 		}
+
+		if change := <-subscription.Changes(); change.Kind != watch.KindUpdated || change.Stock.Price != 100 {
+			t.Fatalf("unexpected change [%v]", change)
+		}
+
+		trail, err := sDb.Changes(context.Background(), model.Symbol{Ticker: stockData.Ticker}, "price", 1)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(trail) != 1 || trail[0].Old != 49.28 || trail[0].New != 100 || trail[0].Cause.Kind != model.CauseUpdater || trail[0].Cause.RunID == "" {
+			t.Fatalf("unexpected changes %+v", trail)
+		}
 	})
 	t.Run("updates pe when pe.nextUpdate is missing", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
@@ -121,21 +143,23 @@ func TestUpdater(t *testing.T) {
 
 		sDb := database.NewStockinfos(db)
 
-		err := sDb.Save(stockData)
+		err := sDb.Save(context.Background(), stockData, model.Cause{Kind: model.CauseRegistration})
 		if err != nil {
 			t.Fatal(err)
 		}
-		defer sDb.Delete(stockData.Ticker)
+		defer sDb.Delete(context.Background(), model.Symbol{Ticker: stockData.Ticker})
 
 		sSC := mocks.NewMockgetStockWithFields(ctrl)
 		stockData.Price = 100
-		sSC.EXPECT().GetWithFields("INTC", []string{"pe"}).Return(stockData, nil)
+		sSC.EXPECT().GetWithFields(gomock.Any(), "INTC", []string{"pe"}).Return(stockData, nil)
 
-		updater := New(sDb, sSC, "1h", "1h", "1h")
+		outbox := database.NewOutbox(db)
+
+		updater := New(sDb, database.NewQuarantine(db), sSC, validation.Rules{}, Intervals{Stock: time.Hour, PeRatio: time.Hour, DividendYield: time.Hour}, nil, events.NewPublisher(outbox))
 
 		updater.UpdateStocks()
 
-		result, err := sDb.Get(stockData.Ticker)
+		result, err := sDb.Get(context.Background(), model.Symbol{Ticker: stockData.Ticker})
 
 		if err != nil {
 			t.Fatal(err)
@@ -144,5 +168,222 @@ func TestUpdater(t *testing.T) {
 		if result.Price != 100 {
 			t.Fatalf("stock is not updated")
 		}
+
+		pending, err := outbox.Pending(context.Background(), 10)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, event := range pending {
+			defer outbox.Remove(context.Background(), event.ID)
+		}
+
+		if len(pending) != 1 || pending[0].Type != model.EventPeHistoryUpdated || pending[0].Stock.Price != 100 {
+			t.Fatalf("unexpected events %+v", pending)
+		}
+	})
+	t.Run("quarantines suspicious price change", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		stockData := model.StockDataInfo{}
+		stockData.Ticker = "INTC"
+		stockData.Dividend = 0.33
+		stockData.Eps = 5.43
+		stockData.Price = 49.28
+		stockData.DividendYield5yr.NextUpdate = time.Now().Add(5000000000)
+		stockData.PeRatio5yr.NextUpdate = time.Now().Add(5000000000)
+
+		sDb := database.NewStockinfos(db)
+		qDb := database.NewQuarantine(db)
+
+		err := sDb.Save(context.Background(), stockData, model.Cause{Kind: model.CauseRegistration})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sDb.Delete(context.Background(), model.Symbol{Ticker: stockData.Ticker})
+		defer qDb.Delete(context.Background(), model.Symbol{Ticker: stockData.Ticker})
+
+		sSC := mocks.NewMockgetStockWithFields(ctrl)
+		proposed := stockData
+		proposed.Price = 4928
+		sSC.EXPECT().GetWithFields(gomock.Any(), "INTC", []string{"price", "eps", "div"}).Return(proposed, nil)
+
+		updater := New(sDb, qDb, sSC, validation.DefaultRules(), Intervals{Stock: time.Hour, PeRatio: time.Hour, DividendYield: time.Hour}, nil, nil)
+
+		updater.UpdateStocks()
+
+		result, err := sDb.Get(context.Background(), model.Symbol{Ticker: stockData.Ticker})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Price != 49.28 {
+			t.Fatalf("suspicious update is applied")
+		}
+
+		entry, err := qDb.Get(context.Background(), model.Symbol{Ticker: stockData.Ticker})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if entry.Proposed.Price != 4928 || len(entry.Violations) != 1 {
+			t.Fatalf("unexpected quarantine entry %+v", entry)
+		}
+	})
+	t.Run("quarantined stock isn't fetched again", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		stockData := model.StockDataInfo{}
+		stockData.Ticker = "INTC"
+		stockData.Dividend = 0.33
+		stockData.Eps = 5.43
+		stockData.Price = 49.28
+		stockData.DividendYield5yr.NextUpdate = time.Now().Add(5000000000)
+		stockData.PeRatio5yr.NextUpdate = time.Now().Add(5000000000)
+
+		sDb := database.NewStockinfos(db)
+		qDb := database.NewQuarantine(db)
+
+		err := sDb.Save(context.Background(), stockData, model.Cause{Kind: model.CauseRegistration})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sDb.Delete(context.Background(), model.Symbol{Ticker: stockData.Ticker})
+		defer qDb.Delete(context.Background(), model.Symbol{Ticker: stockData.Ticker})
+
+		sSC := mocks.NewMockgetStockWithFields(ctrl)
+		proposed := stockData
+		proposed.Price = 4928
+		sSC.EXPECT().GetWithFields(gomock.Any(), "INTC", []string{"price", "eps", "div"}).Return(proposed, nil).Times(1)
+
+		updater := New(sDb, qDb, sSC, validation.DefaultRules(), Intervals{Stock: time.Hour, PeRatio: time.Hour, DividendYield: time.Hour}, nil, nil)
+
+		updater.UpdateStocks()
+
+		first, err := qDb.Get(context.Background(), model.Symbol{Ticker: stockData.Ticker})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		updater.UpdateStocks()
+
+		second, err := qDb.Get(context.Background(), model.Symbol{Ticker: stockData.Ticker})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !second.Created.Equal(first.Created) {
+			t.Fatalf("quarantine entry is recreated at [%v], first [%v]", second.Created, first.Created)
+		}
+	})
+	t.Run("skips stock without expired group", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		now := time.Now()
+		stockData := model.StockDataInfo{}
+		stockData.Ticker = "INTC"
+		stockData.NextUpdate = now.Add(time.Minute)
+		stockData.DividendYield5yr.NextUpdate = now.Add(time.Minute)
+		stockData.PeRatio5yr.NextUpdate = now.Add(time.Minute)
+
+		sSC := mocks.NewMockgetStockWithFields(ctrl)
+		sSC.EXPECT().GetWithFields(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		updater := New(nil, nil, sSC, validation.DefaultRules(), Intervals{Stock: time.Hour, PeRatio: time.Hour, DividendYield: time.Hour}, nil, nil)
+
+		if result := updater.updateStock(context.Background(), stockData, now, model.Cause{Kind: model.CauseUpdater}); result != metrics.ResultSkipped {
+			t.Fatalf("expected skipped, got [%s]", result)
+		}
+	})
+	t.Run("fetches the pinned group again when the override is removed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		stockData := model.StockDataInfo{}
+		stockData.Ticker = "INTC"
+		stockData.Price = 49.28
+		stockData.PeRatio5yr.Avg = 14.89
+		stockData.PeRatio5yr.Min = 8.79
+		stockData.NextUpdate = time.Now().Add(time.Hour)
+		stockData.DividendYield5yr.NextUpdate = time.Now().Add(time.Hour)
+		stockData.PeRatio5yr.NextUpdate = time.Now().Add(time.Hour)
+
+		sDb := database.NewStockinfos(db)
+
+		err := sDb.Save(context.Background(), stockData, model.Cause{Kind: model.CauseRegistration})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sDb.Delete(context.Background(), model.Symbol{Ticker: stockData.Ticker})
+
+		symbol := model.Symbol{Ticker: stockData.Ticker}
+		cause := model.Cause{Kind: model.CauseOverride}
+
+		if _, err := sDb.Override(context.Background(), symbol, []model.Override{{Field: "peRatio5yr.min", Value: 1, Reason: "negative earnings"}}, cause); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := sDb.Override(context.Background(), symbol, []model.Override{}, cause); err != nil {
+			t.Fatal(err)
+		}
+
+		sSC := mocks.NewMockgetStockWithFields(ctrl)
+		sSC.EXPECT().GetWithFields(gomock.Any(), "INTC", []string{"pe"}).Return(stockData, nil)
+
+		updater := New(sDb, database.NewQuarantine(db), sSC, validation.Rules{}, Intervals{Stock: time.Hour, PeRatio: time.Hour, DividendYield: time.Hour}, nil, nil)
+
+		updater.UpdateStocks()
+
+		result, err := sDb.Get(context.Background(), symbol)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.PeRatio5yr.Min != 8.79 {
+			t.Fatalf("expected the provider value, got [%v]", result.PeRatio5yr.Min)
+		}
+	})
+	t.Run("schedules the dividend history of a stock without dividends", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		stockData := model.StockDataInfo{}
+		stockData.Ticker = "BRK.B"
+		stockData.Price = 300
+		stockData.Eps = 20
+		stockData.NextUpdate = time.Now().Add(time.Hour)
+		stockData.PeRatio5yr.NextUpdate = time.Now().Add(time.Hour)
+
+		sDb := database.NewStockinfos(db)
+
+		err := sDb.Save(context.Background(), stockData, model.Cause{Kind: model.CauseRegistration})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sDb.Delete(context.Background(), model.Symbol{Ticker: "BRK", Class: "B"})
+
+		sSC := mocks.NewMockgetStockWithFields(ctrl)
+		sSC.EXPECT().GetWithFields(gomock.Any(), "BRK-B", []string{"divHist"}).Return(model.StockDataInfo{Ticker: "BRK-B"}, nil).Times(1)
+
+		updater := New(sDb, database.NewQuarantine(db), sSC, validation.Rules{}, Intervals{Stock: time.Hour, PeRatio: time.Hour, DividendYield: time.Hour}, nil, nil)
+
+		updater.UpdateStocks()
+		updater.UpdateStocks()
+
+		result, err := sDb.Get(context.Background(), model.Symbol{Ticker: "BRK", Class: "B"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result.UpdateStale(time.Now(), time.Minute)
+
+		if !result.DividendYield5yr.NextUpdate.After(time.Now()) || result.DividendYield5yr.Source != model.SourceProvider || result.Stale {
+			t.Fatalf("dividend history is not scheduled %+v", result.DividendYield5yr)
+		}
 	})
 }
@@ -0,0 +1,165 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-screener/database"
+	"github.com/nagymarci/stock-screener/model"
+)
+
+// more workers than this only gets the service blocked by the provider
+const maxConcurrency = 32
+
+//ValidationError lists the invalid values of the settings
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid settings: " + strings.Join(e.Problems, ", ")
+}
+
+//Validate checks that the settings can be applied
+func Validate(s model.Settings) error {
+	problems := []string{}
+
+	positive := map[string]model.Duration{
+		"stockUpdateInterval": s.StockUpdateInterval,
+		"peUpdateInterval":    s.PeUpdateInterval,
+		"divUpdateInterval":   s.DivUpdateInterval,
+	}
+	for _, name := range []string{"stockUpdateInterval", "peUpdateInterval", "divUpdateInterval"} {
+		if positive[name] <= 0 {
+			problems = append(problems, fmt.Sprintf("%s must be positive", name))
+		}
+	}
+
+	if s.StockUpdateJitter < 0 {
+		problems = append(problems, "stockUpdateJitter must not be negative")
+	}
+	if s.HistoryUpdateJitter < 0 {
+		problems = append(problems, "historyUpdateJitter must not be negative")
+	}
+
+	if _, err := cron.ParseStandard(s.UpdateCron); err != nil {
+		problems = append(problems, fmt.Sprintf("updateCron [%s] is invalid: %v", s.UpdateCron, err))
+	}
+
+	if u, err := url.Parse(s.ProviderURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("providerUrl [%s] is not a http(s) url", s.ProviderURL))
+	}
+
+	if s.Concurrency < 1 || s.Concurrency > maxConcurrency {
+		problems = append(problems, fmt.Sprintf("concurrency must be between 1 and %d", maxConcurrency))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+//Manager holds the current settings and notifies the components when they change
+type Manager struct {
+	mux       sync.Mutex
+	store     *database.Settings
+	current   model.Settings
+	listeners []func(model.Settings)
+}
+
+//New creates a manager starting with the initial settings, which are usually the configuration
+func New(store *database.Settings, initial model.Settings) *Manager {
+	return &Manager{
+		store:   store,
+		current: initial,
+	}
+}
+
+//Load replaces the initial settings with the persisted ones, so changes survive restarts.
+//The settings in effect are validated either way.
+func (m *Manager) Load(ctx context.Context) error {
+	persisted, err := m.store.Get(ctx)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Validate(m.Current())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := Validate(persisted); err != nil {
+		return err
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.current = persisted
+	return nil
+}
+
+//Current returns the settings in effect
+func (m *Manager) Current() model.Settings {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return m.current
+}
+
+//Subscribe calls fn with the current settings and after every change
+func (m *Manager) Subscribe(fn func(model.Settings)) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.listeners = append(m.listeners, fn)
+	fn(m.current)
+}
+
+//Apply validates, persists and propagates the settings, the change is audited with the source and the actor
+func (m *Manager) Apply(ctx context.Context, s model.Settings, source, actor string) (model.Settings, error) {
+	if err := Validate(s); err != nil {
+		return model.Settings{}, err
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if s == m.current {
+		return s, nil
+	}
+
+	change := model.SettingsChange{
+		Previous: m.current,
+		Current:  s,
+		Source:   source,
+		Actor:    actor,
+		Changed:  time.Now(),
+	}
+
+	if err := m.store.Save(ctx, change); err != nil {
+		return model.Settings{}, err
+	}
+
+	m.current = s
+	for _, listener := range m.listeners {
+		listener(s)
+	}
+
+	return s, nil
+}
+
+//History returns the latest changes, newest first
+func (m *Manager) History(ctx context.Context, limit int64) ([]model.SettingsChange, error) {
+	return m.store.History(ctx, limit)
+}
@@ -0,0 +1,81 @@
+package settings
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nagymarci/stock-screener/model"
+)
+
+func validSettings() model.Settings {
+	return model.Settings{
+		StockUpdateInterval: model.Duration(time.Hour),
+		PeUpdateInterval:    model.Duration(168 * time.Hour),
+		DivUpdateInterval:   model.Duration(168 * time.Hour),
+		StockUpdateJitter:   model.Duration(30 * time.Minute),
+		HistoryUpdateJitter: model.Duration(24 * time.Hour),
+		UpdateCron:          "CRON_TZ=America/New_York * 9-17 * * MON-FRI",
+		ProviderURL:         "http://localhost:8081/stockinfo/",
+		Concurrency:         4,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("accepts valid settings", func(t *testing.T) {
+		if err := Validate(validSettings()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("reports every invalid value", func(t *testing.T) {
+		s := validSettings()
+		s.PeUpdateInterval = 0
+		s.HistoryUpdateJitter = model.Duration(-time.Hour)
+		s.UpdateCron = "hourly"
+		s.ProviderURL = "localhost"
+		s.Concurrency = 0
+
+		err := Validate(s)
+
+		validationErr, ok := err.(*ValidationError)
+		if !ok {
+			t.Fatalf("expected validation error, got [%v]", err)
+		}
+
+		if len(validationErr.Problems) != 5 {
+			t.Fatalf("expected 5 problems, got %v", validationErr.Problems)
+		}
+	})
+
+	t.Run("partial update keeps the other settings", func(t *testing.T) {
+		s := validSettings()
+
+		if err := json.Unmarshal([]byte(`{"stockUpdateInterval":"90m","concurrency":8}`), &s); err != nil {
+			t.Fatal(err)
+		}
+
+		if s.StockUpdateInterval != model.Duration(90*time.Minute) || s.Concurrency != 8 || s.PeUpdateInterval != model.Duration(168*time.Hour) {
+			t.Fatalf("unexpected settings [%+v]", s)
+		}
+	})
+}
+
+func TestChanged(t *testing.T) {
+	current := validSettings()
+	current.Concurrency = 8
+
+	previous := validSettings()
+	loaded := validSettings()
+	loaded.UpdateCron = "0 * * * *"
+
+	result := changed(current, previous, loaded)
+
+	if result.UpdateCron != "0 * * * *" {
+		t.Fatalf("expected the changed cron to be applied, got [%s]", result.UpdateCron)
+	}
+
+	if result.Concurrency != 8 {
+		t.Fatalf("expected the concurrency changed through the api to be kept, got [%d]", result.Concurrency)
+	}
+}
@@ -0,0 +1,74 @@
+package settings
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/nagymarci/stock-screener/model"
+	"github.com/sirupsen/logrus"
+)
+
+//Watch polls the modification time of the file and applies the settings changed since the last load when it changes,
+//so the ones changed through the api are kept. loaded is the configuration the server started with.
+//It returns when the context is done.
+func Watch(ctx context.Context, m *Manager, file string, interval time.Duration, loaded model.Settings, load func() (model.Settings, error)) {
+	log := logrus.WithField("component", "settingsWatcher").WithField("file", file)
+
+	lastModified := modTime(file)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modified := modTime(file)
+		if modified.Equal(lastModified) {
+			continue
+		}
+		lastModified = modified
+
+		s, err := load()
+		if err != nil {
+			log.Errorf("Ignoring changed configuration: %v\n", err)
+			continue
+		}
+
+		if _, err := m.Apply(ctx, changed(m.Current(), loaded, s), model.SettingsSourceFile, file); err != nil {
+			log.Errorf("Failed to apply changed configuration: %v\n", err)
+			continue
+		}
+		loaded = s
+
+		log.Infoln("Applied changed configuration")
+	}
+}
+
+// changed returns the current settings with the ones that differ between the previous and the new load
+func changed(current, previous, loaded model.Settings) model.Settings {
+	result := reflect.ValueOf(&current).Elem()
+	before, after := reflect.ValueOf(previous), reflect.ValueOf(loaded)
+
+	for i := 0; i < result.NumField(); i++ {
+		if before.Field(i).Interface() != after.Field(i).Interface() {
+			result.Field(i).Set(after.Field(i))
+		}
+	}
+
+	return current
+}
+
+func modTime(file string) time.Time {
+	info, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
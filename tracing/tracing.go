@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/nagymarci/stock-screener"
+	serviceName         = "stock-screener"
+)
+
+//ExporterFactory creates the span exporter of a tracing backend
+type ExporterFactory func(ctx context.Context) (sdktrace.SpanExporter, error)
+
+var exporters = map[string]ExporterFactory{
+	"stdout": func(ctx context.Context) (sdktrace.SpanExporter, error) {
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	},
+	// the endpoint and headers are read from the standard OTEL_EXPORTER_OTLP_* environment variables
+	"otlp": func(ctx context.Context) (sdktrace.SpanExporter, error) {
+		return otlptracehttp.New(ctx)
+	},
+}
+
+//RegisterExporter makes an exporter available by name
+func RegisterExporter(name string, factory ExporterFactory) {
+	exporters[name] = factory
+}
+
+//Init installs the global tracer provider with the named exporter, an empty name or "none" disables exporting.
+//The returned function flushes and stops the provider.
+func Init(ctx context.Context, exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if exporter == "" || exporter == "none" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	factory, ok := exporters[exporter]
+	if !ok {
+		return nil, fmt.Errorf("unknown tracing exporter [%s], available: %s", exporter, strings.Join(exporterNames(), ", "))
+	}
+
+	spanExporter, err := factory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(spanExporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func exporterNames() []string {
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+//Start starts a span for the operation as the child of the span in the context
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+//Error records the error on the span and returns it
+func Error(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
+
+//End records the error pointed to on the span and ends it, meant to be deferred with a named error result
+func End(span trace.Span, err *error) {
+	Error(span, *err)
+	span.End()
+}
@@ -0,0 +1,109 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/nagymarci/stock-screener/model"
+)
+
+//Rules configures the checks of the provider data, a zero limit disables the check
+type Rules struct {
+	//MaxPrice is the highest accepted price
+	MaxPrice float64
+	//MaxPeRatio is the highest accepted 5yr average P/E
+	MaxPeRatio float64
+	//MaxDividendYield is the highest accepted 5yr dividend yield in percent
+	MaxDividendYield float64
+	//MaxPriceChange is the highest accepted price change compared to the stored value in percent
+	MaxPriceChange float64
+	//MaxDividendChange is the highest accepted dividend change compared to the stored value in percent
+	MaxDividendChange float64
+}
+
+//DefaultRules returns the rules used when nothing is configured
+func DefaultRules() Rules {
+	return Rules{
+		MaxPrice:          1000000,
+		MaxPeRatio:        1000,
+		MaxDividendYield:  50,
+		MaxPriceChange:    50,
+		MaxDividendChange: 100,
+	}
+}
+
+//Validate checks the fields of the proposed update requested from the provider against the stored stock
+func (r Rules) Validate(previous, proposed model.StockDataInfo, fields []string) []model.Violation {
+	var violations []model.Violation
+
+	add := func(field, format string, args ...interface{}) {
+		violations = append(violations, model.Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if contains(fields, "price") {
+		if proposed.Price <= 0 {
+			add("price", "price [%v] must be positive", proposed.Price)
+		} else if r.MaxPrice > 0 && proposed.Price > r.MaxPrice {
+			add("price", "price [%v] is above [%v]", proposed.Price, r.MaxPrice)
+		}
+
+		if change := percentChange(previous.Price, proposed.Price); r.MaxPriceChange > 0 && change > r.MaxPriceChange {
+			add("price", "price changed by [%.1f%%] from [%v] to [%v]", change, previous.Price, proposed.Price)
+		}
+	}
+
+	if contains(fields, "div") {
+		if proposed.Dividend < 0 {
+			add("dividend", "dividend [%v] is negative", proposed.Dividend)
+		}
+
+		if change := percentChange(previous.Dividend, proposed.Dividend); r.MaxDividendChange > 0 && change > r.MaxDividendChange {
+			add("dividend", "dividend changed by [%.1f%%] from [%v] to [%v]", change, previous.Dividend, proposed.Dividend)
+		}
+	}
+
+	if contains(fields, "pe") {
+		pe := proposed.PeRatio5yr
+		switch {
+		case pe.Avg < 0 || pe.Min < 0:
+			add("peRatio5yr", "avg [%v] and min [%v] must not be negative", pe.Avg, pe.Min)
+		case pe.Min > pe.Avg:
+			add("peRatio5yr", "min [%v] is greater than avg [%v]", pe.Min, pe.Avg)
+		case r.MaxPeRatio > 0 && pe.Avg > r.MaxPeRatio:
+			add("peRatio5yr", "avg [%v] is above [%v]", pe.Avg, r.MaxPeRatio)
+		}
+	}
+
+	if contains(fields, "divHist") {
+		yield := proposed.DividendYield5yr
+		switch {
+		case yield.Avg < 0 || yield.Max < 0:
+			add("dividendYield5yr", "avg [%v] and max [%v] must not be negative", yield.Avg, yield.Max)
+		case yield.Avg > yield.Max:
+			add("dividendYield5yr", "avg [%v] is greater than max [%v]", yield.Avg, yield.Max)
+		case r.MaxDividendYield > 0 && yield.Max > r.MaxDividendYield:
+			add("dividendYield5yr", "max [%v] is above [%v]", yield.Max, r.MaxDividendYield)
+		}
+	}
+
+	return violations
+}
+
+//percentChange returns the absolute change in percent, 0 when there is no previous value to compare to
+func percentChange(previous, current float64) float64 {
+	if previous == 0 {
+		return 0
+	}
+
+	return math.Abs(current-previous) / math.Abs(previous) * 100
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/nagymarci/stock-screener/model"
+)
+
+func TestValidate(t *testing.T) {
+	previous := model.StockDataInfo{}
+	previous.Ticker = "INTC"
+	previous.Price = 49.28
+	previous.Dividend = 0.33
+
+	t.Run("accepts consistent update", func(t *testing.T) {
+		proposed := previous
+		proposed.Price = 50
+		proposed.PeRatio5yr.Avg = 14.89
+		proposed.PeRatio5yr.Min = 8.79
+
+		violations := DefaultRules().Validate(previous, proposed, []string{"price", "eps", "div", "pe"})
+
+		if len(violations) != 0 {
+			t.Fatalf("unexpected violations %v", violations)
+		}
+	})
+	t.Run("rejects price far from the previous value", func(t *testing.T) {
+		proposed := previous
+		proposed.Price = 4928
+
+		violations := DefaultRules().Validate(previous, proposed, []string{"price", "eps", "div"})
+
+		if len(violations) != 1 || violations[0].Field != "price" {
+			t.Fatalf("unexpected violations %v", violations)
+		}
+	})
+	t.Run("rejects negative dividend", func(t *testing.T) {
+		proposed := previous
+		proposed.Dividend = -0.33
+
+		violations := Rules{}.Validate(previous, proposed, []string{"price", "eps", "div"})
+
+		if len(violations) != 1 || violations[0].Field != "dividend" {
+			t.Fatalf("unexpected violations %v", violations)
+		}
+	})
+	t.Run("rejects pe min above avg", func(t *testing.T) {
+		proposed := model.StockDataInfo{}
+		proposed.PeRatio5yr.Avg = 8.79
+		proposed.PeRatio5yr.Min = 14.89
+
+		violations := Rules{}.Validate(previous, proposed, []string{"pe"})
+
+		if len(violations) != 1 || violations[0].Field != "peRatio5yr" {
+			t.Fatalf("unexpected violations %v", violations)
+		}
+	})
+	t.Run("checks only the requested fields", func(t *testing.T) {
+		proposed := model.StockDataInfo{}
+
+		violations := DefaultRules().Validate(previous, proposed, []string{"divHist"})
+
+		if len(violations) != 0 {
+			t.Fatalf("unexpected violations %v", violations)
+		}
+	})
+}
@@ -0,0 +1,253 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nagymarci/stock-screener/model"
+)
+
+//Kind tells how the stock changed
+type Kind string
+
+const (
+	KindRegistered Kind = "registered"
+	KindUpdated    Kind = "updated"
+	KindDeleted    Kind = "deleted"
+)
+
+// changes kept for resuming subscribers
+const historySize = 1024
+
+//ErrLagged is reported by subscriptions closed because they didn't keep up with the changes
+var ErrLagged = errors.New("subscriber is too slow")
+
+//ErrClosed is reported by subscriptions ended because the hub was closed
+var ErrClosed = errors.New("hub is closed")
+
+//ErrExpired is returned when the changes after the id are not kept anymore, or the id is of an earlier process
+var ErrExpired = errors.New("change id expired")
+
+//Change is the stock after it was written, only the ticker is set for deleted stocks
+type Change struct {
+	//ID orders the changes of the hub, it's unique across restarts
+	ID    string
+	Kind  Kind
+	Stock model.StockDataInfo
+	Time  time.Time
+}
+
+//Hub fans the changes out to the subscribers, publishing never blocks on a slow subscriber
+type Hub struct {
+	mux         sync.Mutex
+	subscribers map[*Subscription]bool
+	// the ids are the start of the hub and the sequence number of the change
+	epoch    int64
+	sequence uint64
+	history  []Change
+	closed   bool
+}
+
+//NewHub returns a hub without subscribers
+func NewHub() *Hub {
+	return &Hub{subscribers: map[*Subscription]bool{}, epoch: time.Now().UnixNano()}
+}
+
+//Publish sends the change to the subscribers of the ticker, a nil hub drops it
+func (h *Hub) Publish(kind Kind, stock model.StockDataInfo) {
+	if h == nil {
+		return
+	}
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.sequence++
+	change := Change{ID: fmt.Sprintf("%d-%d", h.epoch, h.sequence), Kind: kind, Stock: stock, Time: time.Now()}
+
+	if len(h.history) == historySize {
+		h.history = h.history[1:]
+	}
+	h.history = append(h.history, change)
+
+	for s := range h.subscribers {
+		if !s.matches(stock.Ticker) {
+			continue
+		}
+
+		select {
+		case s.changes <- change:
+		default:
+			// dropping a change silently would leave the subscriber with a wrong view, so it's closed instead
+			s.err = ErrLagged
+			h.remove(s)
+		}
+	}
+}
+
+//Close ends the subscriptions with ErrClosed, so the streams don't keep the shutdown waiting.
+//Later subscriptions are closed right away, a nil hub is ignored.
+func (h *Hub) Close() {
+	if h == nil {
+		return
+	}
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.closed = true
+	for s := range h.subscribers {
+		s.err = ErrClosed
+		h.remove(s)
+	}
+}
+
+//Subscribe returns the changes of the tickers, every change when tickers is empty.
+//Up to buffer changes are queued for the subscriber before it's closed with ErrLagged.
+func (h *Hub) Subscribe(tickers []string, buffer int) *Subscription {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	return h.subscribe(tickers, buffer, nil)
+}
+
+//Resume subscribes like Subscribe, starting with the kept changes published after the one with the id
+func (h *Hub) Resume(id string, tickers []string, buffer int) (*Subscription, error) {
+	var epoch int64
+	var sequence uint64
+	if _, err := fmt.Sscanf(id, "%d-%d", &epoch, &sequence); err != nil {
+		return nil, fmt.Errorf("%w: [%s]", ErrExpired, id)
+	}
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	// the change right after the id must still be in the history
+	oldest := h.sequence - uint64(len(h.history)) + 1
+	if epoch != h.epoch || sequence > h.sequence || sequence+1 < oldest {
+		return nil, fmt.Errorf("%w: [%s]", ErrExpired, id)
+	}
+
+	return h.subscribe(tickers, buffer, h.history[sequence+1-oldest:]), nil
+}
+
+//Watchlist returns a subscription receiving the changes of the tickers added to it
+func (h *Hub) Watchlist(buffer int) *Subscription {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	s := h.subscribe(nil, buffer, nil)
+	s.tickers = map[string]bool{}
+
+	return s
+}
+
+func (h *Hub) subscribe(tickers []string, buffer int, missed []Change) *Subscription {
+	s := &Subscription{hub: h}
+
+	if len(tickers) > 0 {
+		s.tickers = map[string]bool{}
+		for _, t := range tickers {
+			s.tickers[t] = true
+		}
+	}
+
+	var queued []Change
+	for _, change := range missed {
+		if s.matches(change.Stock.Ticker) {
+			queued = append(queued, change)
+		}
+	}
+
+	s.changes = make(chan Change, buffer+len(queued))
+	for _, change := range queued {
+		s.changes <- change
+	}
+
+	if h.closed {
+		s.err = ErrClosed
+		close(s.changes)
+		return s
+	}
+
+	h.subscribers[s] = true
+
+	return s
+}
+
+func (h *Hub) remove(s *Subscription) {
+	if h.subscribers[s] {
+		delete(h.subscribers, s)
+		close(s.changes)
+	}
+}
+
+//Subscription receives the changes until it's closed
+type Subscription struct {
+	hub     *Hub
+	tickers map[string]bool
+	changes chan Change
+	err     error
+}
+
+func (s *Subscription) matches(ticker string) bool {
+	return s.tickers == nil || s.tickers[ticker]
+}
+
+//Add starts receiving the changes of the tickers, the subscription must be a watchlist
+func (s *Subscription) Add(tickers ...string) {
+	s.hub.mux.Lock()
+	defer s.hub.mux.Unlock()
+
+	for _, t := range tickers {
+		s.tickers[t] = true
+	}
+}
+
+//Remove stops receiving the changes of the tickers, the subscription must be a watchlist
+func (s *Subscription) Remove(tickers ...string) {
+	s.hub.mux.Lock()
+	defer s.hub.mux.Unlock()
+
+	for _, t := range tickers {
+		delete(s.tickers, t)
+	}
+}
+
+//Tickers returns the tickers of the watchlist
+func (s *Subscription) Tickers() []string {
+	s.hub.mux.Lock()
+	defer s.hub.mux.Unlock()
+
+	result := make([]string, 0, len(s.tickers))
+	for t := range s.tickers {
+		result = append(result, t)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+//Changes is closed when the subscription ends
+func (s *Subscription) Changes() <-chan Change {
+	return s.changes
+}
+
+//Err tells why the changes were closed, nil when the subscriber closed it
+func (s *Subscription) Err() error {
+	s.hub.mux.Lock()
+	defer s.hub.mux.Unlock()
+
+	return s.err
+}
+
+//Close stops the changes, it can be called more than once
+func (s *Subscription) Close() {
+	s.hub.mux.Lock()
+	defer s.hub.mux.Unlock()
+
+	s.hub.remove(s)
+}
@@ -0,0 +1,137 @@
+package watch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nagymarci/stock-screener/model"
+)
+
+func TestHub(t *testing.T) {
+	t.Run("filters by ticker", func(t *testing.T) {
+		hub := NewHub()
+		s := hub.Subscribe([]string{"INTC"}, 2)
+		defer s.Close()
+
+		hub.Publish(KindUpdated, model.StockDataInfo{Ticker: "MSFT"})
+		hub.Publish(KindUpdated, model.StockDataInfo{Ticker: "INTC"})
+
+		change := <-s.Changes()
+		if change.Stock.Ticker != "INTC" || change.Kind != KindUpdated {
+			t.Fatalf("unexpected change [%v]", change)
+		}
+
+		if len(s.Changes()) != 0 {
+			t.Fatalf("expected no more changes, got [%d]", len(s.Changes()))
+		}
+	})
+
+	t.Run("closes slow subscribers", func(t *testing.T) {
+		hub := NewHub()
+		s := hub.Subscribe(nil, 1)
+
+		hub.Publish(KindRegistered, model.StockDataInfo{Ticker: "INTC"})
+		hub.Publish(KindDeleted, model.StockDataInfo{Ticker: "INTC"})
+
+		<-s.Changes()
+		if _, open := <-s.Changes(); open {
+			t.Fatal("expected closed changes")
+		}
+
+		if s.Err() != ErrLagged {
+			t.Fatalf("expected lagged, got [%v]", s.Err())
+		}
+
+		s.Close()
+	})
+
+	t.Run("close ends the subscriptions", func(t *testing.T) {
+		hub := NewHub()
+		s := hub.Subscribe(nil, 1)
+
+		hub.Close()
+
+		if _, open := <-s.Changes(); open || s.Err() != ErrClosed {
+			t.Fatalf("expected closed changes, got [%v]", s.Err())
+		}
+
+		later := hub.Watchlist(1)
+		if _, open := <-later.Changes(); open || later.Err() != ErrClosed {
+			t.Fatalf("expected closed watchlist, got [%v]", later.Err())
+		}
+
+		s.Close()
+		later.Close()
+	})
+
+	t.Run("nil hub drops changes", func(t *testing.T) {
+		var hub *Hub
+		hub.Publish(KindUpdated, model.StockDataInfo{Ticker: "INTC"})
+	})
+
+	t.Run("resumes after the id", func(t *testing.T) {
+		hub := NewHub()
+		first := hub.Subscribe(nil, 3)
+		defer first.Close()
+
+		hub.Publish(KindUpdated, model.StockDataInfo{Ticker: "INTC", Price: 1})
+		hub.Publish(KindUpdated, model.StockDataInfo{Ticker: "MSFT", Price: 2})
+		hub.Publish(KindUpdated, model.StockDataInfo{Ticker: "INTC", Price: 3})
+
+		last := <-first.Changes()
+
+		s, err := hub.Resume(last.ID, []string{"INTC"}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+
+		if change := <-s.Changes(); change.Stock.Price != 3 {
+			t.Fatalf("expected the change after [%s], got [%v]", last.ID, change)
+		}
+
+		hub.Publish(KindDeleted, model.StockDataInfo{Ticker: "INTC"})
+
+		if change := <-s.Changes(); change.Kind != KindDeleted {
+			t.Fatalf("expected the published change, got [%v]", change)
+		}
+	})
+
+	t.Run("rejects expired ids", func(t *testing.T) {
+		hub := NewHub()
+		s := hub.Subscribe(nil, 1)
+		hub.Publish(KindUpdated, model.StockDataInfo{Ticker: "INTC"})
+		first := <-s.Changes()
+		s.Close()
+
+		for i := 0; i < historySize+1; i++ {
+			hub.Publish(KindUpdated, model.StockDataInfo{Ticker: "INTC"})
+		}
+
+		for _, id := range []string{first.ID, "1-1", "invalid"} {
+			if _, err := hub.Resume(id, nil, 1); !errors.Is(err, ErrExpired) {
+				t.Errorf("expected [%s] to be expired, got [%v]", id, err)
+			}
+		}
+	})
+
+	t.Run("watchlist receives the added tickers", func(t *testing.T) {
+		hub := NewHub()
+		s := hub.Watchlist(2)
+		defer s.Close()
+
+		hub.Publish(KindUpdated, model.StockDataInfo{Ticker: "INTC", Price: 1})
+		s.Add("INTC", "MSFT")
+		s.Remove("MSFT")
+		hub.Publish(KindUpdated, model.StockDataInfo{Ticker: "MSFT", Price: 2})
+		hub.Publish(KindUpdated, model.StockDataInfo{Ticker: "INTC", Price: 3})
+
+		if change := <-s.Changes(); change.Stock.Price != 3 {
+			t.Fatalf("unexpected change [%v]", change)
+		}
+
+		if tickers := s.Tickers(); len(tickers) != 1 || tickers[0] != "INTC" {
+			t.Fatalf("unexpected tickers [%v]", tickers)
+		}
+	})
+}